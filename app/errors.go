@@ -18,6 +18,11 @@ func (err *AppError) Error() string {
 }
 
 func (err *AppError) Log() {
+	// Status errors like NotFound don't have an original error
+	if err.OriginalErr == nil {
+		return
+	}
+
 	if !strings.Contains(err.OriginalErr.Error(), "exec error:") {
 		log.Printf("ERROR: %s\n", err.Error())
 	}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Compresses the response of the handler if the client accepts gzip.
+func Gzip(fn Handler) Handler {
+	return func(r *Request) (err error) {
+		r.W.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Req.Header.Get("Accept-Encoding")) {
+			return fn(r)
+		}
+
+		orig := r.W
+		w := &gzipResponseWriter{ResponseWriter: orig}
+		r.W = w
+
+		// Restored even if the handler panics, to report the error. Once
+		// the body has started the error page can't be written anymore.
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = Errorf("panic recovered error: %s", rec)
+			}
+
+			started := w.close()
+			r.W = orig
+
+			if err != nil && started {
+				if e, ok := err.(*AppError); ok {
+					e.Log()
+				} else {
+					log.Printf("ERROR: %s\n", err)
+				}
+				err = nil
+			}
+		}()
+
+		return fn(r)
+	}
+}
+
+// Returns true if the Accept-Encoding header accepts gzip, explicitly
+// or with *, and its quality is not zero.
+func acceptsGzip(header string) bool {
+	accepted := false
+	for _, token := range strings.Split(header, ",") {
+		parts := strings.Split(token, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				value, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					value = 0
+				}
+				q = value
+			}
+		}
+
+		// An explicit gzip has priority over *
+		if coding == "gzip" {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+// Response writer that only starts compressing when the handler writes
+// something, so the errors can be reported as usual. The responses
+// without body are passed as they are.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+
+	// The header has been sent without compression
+	plain bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		w.plain = true
+	} else {
+		w.start()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.plain {
+		return w.ResponseWriter.Write(b)
+	}
+	w.start()
+	return w.gz.Write(b)
+}
+
+func (w *gzipResponseWriter) start() {
+	if w.gz != nil || w.plain {
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+}
+
+// Finishes the compressed stream. Returns true if the
+// response had already started.
+func (w *gzipResponseWriter) close() bool {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+	return w.gz != nil || w.plain
+}
@@ -1,24 +1,36 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
+	"bytes"
 	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/ernestokarim/closurer/app"
 	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/css"
+	"github.com/ernestokarim/closurer/digest"
 	"github.com/ernestokarim/closurer/js"
+	"github.com/ernestokarim/closurer/manifest"
+	"github.com/ernestokarim/closurer/output"
 )
 
 var (
-	mapping = map[string]string{}
+	mapping = manifest.Mapping{}
+
+	// Outputs of the current target, and the ones with a hash in their names
+	outputs       = manifest.Mapping{}
+	fingerprinted = manifest.Mapping{}
+
+	// Subresource Integrity values of the JS & CSS outputs
+	integrities = manifest.Mapping{}
 )
 
 func build() error {
+	outputs = manifest.Mapping{}
+
 	if err := js.FullCompile(); err != nil {
 		return err
 	}
@@ -31,32 +43,102 @@ func build() error {
 		return err
 	}
 
+	if err := calcIntegrities(); err != nil {
+		return err
+	}
+
+	if err := outputHtml(); err != nil {
+		return err
+	}
+
+	if err := compressOutputs(); err != nil {
+		return err
+	}
+
 	if err := outputMap(); err != nil {
 		return err
 	}
 
+	if err := updateHistory(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func copyCssFile() error {
 	conf := config.Current()
-	target := conf.Gss.CurTarget()
 
-	if conf.Gss == nil {
+	// JS-only target
+	target := conf.Gss.CurTarget()
+	if target == nil {
 		return nil
 	}
 
-	srcName := filepath.Join(conf.Build, config.CSS_NAME)
-	filename := target.Output
-	if strings.Contains(filename, "{sha1}") {
-		sha1, err := calcFileSha1(srcName)
-		if err != nil {
+	key := config.SelectedTarget + "-css"
+
+	if len(conf.Gss.Inputs) > 0 || len(conf.Gss.Css) > 0 {
+		srcName := filepath.Join(conf.Build, config.CSS_NAME)
+		if err := copyCss(srcName, target.Output, key, conf.Gss.Css, target.Assets); err != nil {
+			return err
+		}
+
+		if target.Rtl == "true" {
+			srcName := filepath.Join(conf.Build, config.CSS_RTL_NAME)
+			err := copyCss(srcName, target.RtlOutput, key+"-rtl", conf.Gss.Css,
+				target.Assets)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, b := range conf.Gss.Bundles {
+		srcName := filepath.Join(conf.Build, config.BundleCssName(b.Name, false))
+		if err := copyCss(srcName, b.Output, key+"-"+b.Name, b.Css, target.Assets); err != nil {
+			return err
+		}
+
+		if target.Rtl == "true" {
+			srcName := filepath.Join(conf.Build, config.BundleCssName(b.Name, true))
+			err := copyCss(srcName, b.RtlOutput, key+"-rtl-"+b.Name, b.Css, target.Assets)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Copies a compiled stylesheet to its output file, saving the final
+// name in the mapping with the key. The plain CSS files are minified
+// and appended to the compiled code. If there's an assets folder the
+// referenced files are fingerprinted too.
+func copyCss(srcName, filename, key string, plain []*config.InputNode, assets string) error {
+	if len(plain) > 0 {
+		fullName := strings.TrimSuffix(srcName, ".css") + "-full.css"
+		if err := appendPlainCss(srcName, fullName, filepath.Dir(filename), plain); err != nil {
+			return err
+		}
+		srcName = fullName
+	}
+
+	if assets != "" {
+		assetsName := strings.TrimSuffix(srcName, ".css") + "-assets.css"
+		if err := fingerprintAssets(srcName, assetsName, filepath.Dir(filename), assets); err != nil {
 			return err
 		}
-		filename = strings.Replace(filename, "{sha1}", sha1, -1)
+		srcName = assetsName
 	}
 
-	mapping[config.SelectedTarget+"-css"] = filename
+	pattern := filename
+	filename, err := digest.Expand(pattern, srcName)
+	if err != nil {
+		return err
+	}
+
+	addOutput(key, pattern, filename)
 
 	if err := copyFile(srcName, filename); err != nil {
 		return err
@@ -65,26 +147,102 @@ func copyCssFile() error {
 	return nil
 }
 
+// Writes to dest the compiled stylesheet followed by the plain CSS files,
+// with their url() references relative to the output folder.
+func appendPlainCss(srcName, dest, outputDir string, plain []*config.InputNode) error {
+	if err := copyFile(srcName, dest); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return app.Error(err)
+	}
+	defer f.Close()
+
+	files := []string{}
+	for _, n := range plain {
+		files = append(files, n.File)
+	}
+
+	if err := css.Bundle(f, files, outputDir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Writes to dest the compiled stylesheet with the referenced assets
+// replaced by their fingerprinted copies, and adds them to the mapping.
+func fingerprintAssets(srcName, dest, cssDir, assets string) error {
+	content, err := ioutil.ReadFile(srcName)
+	if err != nil {
+		return app.Error(err)
+	}
+
+	result, files, err := css.Fingerprint(string(content), cssDir, assets)
+	if err != nil {
+		return err
+	}
+
+	for orig, hashed := range files {
+		key := config.SelectedTarget + "-asset-" + filepath.ToSlash(config.RelPath(orig))
+		mapping[key] = config.RelPath(hashed)
+		fingerprinted[key] = hashed
+	}
+
+	if err := ioutil.WriteFile(dest, []byte(result), 0644); err != nil {
+		return app.Error(err)
+	}
+
+	return nil
+}
+
 func copyJsFile() error {
 	conf := config.Current()
-	target := conf.Js.CurTarget()
 
-	if conf.Js == nil {
+	// GSS-only target
+	target := conf.Js.CurTarget()
+	if target == nil {
 		return nil
 	}
 
-	srcName := filepath.Join(conf.Build, config.JS_NAME)
+	if err := copyJs(config.JS_NAME, target.Output, "-js"); err != nil {
+		return err
+	}
+	if target.LegacyOutput != "" {
+		if err := copyJs(config.JS_LEGACY_NAME, target.LegacyOutput, "-js-legacy"); err != nil {
+			return err
+		}
+	}
 
-	filename := filepath.Join(conf.Js.Root, target.Output)
-	if strings.Contains(filename, "{sha1}") {
-		sha1, err := calcFileSha1(srcName)
-		if err != nil {
+	// Externs for the consumers of a library
+	if len(target.Exports) > 0 && target.ExternsOutput != "" {
+		filename := filepath.Join(conf.Js.Root, target.ExternsOutput)
+		externs := []byte(js.LibraryExterns(target))
+		if err := output.WriteFile(filename, externs); err != nil {
 			return err
 		}
-		filename = strings.Replace(filename, "{sha1}", sha1, -1)
+
+		addOutput(config.SelectedTarget+"-externs", filename, filename)
 	}
 
-	mapping[config.SelectedTarget+"-js"] = filename
+	return nil
+}
+
+// Copies a compiled file of the build folder to its output,
+// adding the prepends at the beginning.
+func copyJs(name, output, suffix string) error {
+	conf := config.Current()
+	srcName := filepath.Join(conf.Build, name)
+
+	pattern := filepath.Join(conf.Js.Root, output)
+	filename, err := digest.Expand(pattern, srcName)
+	if err != nil {
+		return err
+	}
+
+	addOutput(config.SelectedTarget+suffix, pattern, filename)
 
 	files := []string{}
 	for _, n := range conf.Js.Prepends {
@@ -92,38 +250,109 @@ func copyJsFile() error {
 	}
 	files = append(files, srcName)
 
-	if err := copyFiles(files, filename); err != nil {
-		return err
+	return copyFiles(files, filename)
+}
+
+// Saves the final name of an output in the mapping, remembering it
+// if it has been fingerprinted.
+func addOutput(key, pattern, filename string) {
+	mapping[key] = config.RelPath(filename)
+	outputs[key] = filename
+	if digest.HasPlaceholder(pattern) {
+		fingerprinted[key] = filename
+	}
+}
+
+func outputMap() error {
+	conf := config.Current()
+
+	for _, m := range conf.Maps {
+		if err := writeMap(m); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func calcFileSha1(filename string) (string, error) {
-	cmd := exec.Command("sha1sum", filename)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", app.Error(err)
+func writeMap(m *config.MapNode) error {
+	if m.Integrity == "true" {
+		return writeIntegrityMap(m)
+	}
+
+	result := manifest.Mapping{}
+
+	// Read the entries of the previous builds
+	if m.Merge == "true" {
+		f, err := os.Open(m.File)
+		if err != nil && !os.IsNotExist(err) {
+			return app.Error(err)
+		} else if err == nil {
+			defer f.Close()
+
+			old, err := manifest.Read(f, m.Format)
+			if err != nil {
+				return err
+			}
+			result.Merge(old)
+		}
+	}
+
+	result.Merge(mapping)
+
+	buf := bytes.NewBuffer(nil)
+	if err := manifest.Write(buf, result, m.Format, m.Package); err != nil {
+		return err
 	}
 
-	return strings.Split(string(output), " ")[0], nil
+	return output.Write(m.File, buf)
 }
 
-func outputMap() error {
-	conf := config.Current()
-	if conf.Map == nil {
-		return nil
+// Writes the mapping with the URL and the integrity value of each output.
+func writeIntegrityMap(m *config.MapNode) error {
+	result := map[string]*manifest.Entry{}
+
+	// Read the entries of the previous builds
+	if m.Merge == "true" {
+		f, err := os.Open(m.File)
+		if err != nil && !os.IsNotExist(err) {
+			return app.Error(err)
+		} else if err == nil {
+			defer f.Close()
+
+			result, err = manifest.ReadEntries(f, m.Format)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	f, err := os.Create(conf.Map.File)
-	if err != nil {
-		return app.Error(err)
+	for k, v := range mapping {
+		result[k] = &manifest.Entry{Url: v, Integrity: integrities[k]}
 	}
-	defer f.Close()
 
-	fmt.Fprintf(f, "var mapping = ")
-	if err := json.NewEncoder(f).Encode(&mapping); err != nil {
-		return app.Error(err)
+	buf := bytes.NewBuffer(nil)
+	if err := manifest.WriteEntries(buf, result, m.Format); err != nil {
+		return err
+	}
+
+	return output.Write(m.File, buf)
+}
+
+// Calculates the integrity values of the JS & CSS outputs
+// of the current target.
+func calcIntegrities() error {
+	for key, f := range outputs {
+		ext := filepath.Ext(f)
+		if ext != ".js" && ext != ".css" {
+			continue
+		}
+
+		integrity, err := digest.Integrity(f)
+		if err != nil {
+			return err
+		}
+		integrities[key] = integrity
 	}
 
 	return nil
@@ -136,17 +365,7 @@ func copyFile(from, to string) error {
 	}
 	defer src.Close()
 
-	dest, err := os.Create(to)
-	if err != nil {
-		return app.Error(err)
-	}
-	defer dest.Close()
-
-	if _, err = io.Copy(dest, src); err != nil {
-		return app.Error(err)
-	}
-
-	return nil
+	return output.Write(to, src)
 }
 
 func copyFiles(from []string, to string) error {
@@ -161,17 +380,5 @@ func copyFiles(from []string, to string) error {
 		srcs = append(srcs, src)
 	}
 
-	src := io.MultiReader(srcs...)
-
-	dest, err := os.Create(to)
-	if err != nil {
-		return app.Error(err)
-	}
-	defer dest.Close()
-
-	if _, err = io.Copy(dest, src); err != nil {
-		return app.Error(err)
-	}
-
-	return nil
+	return output.Write(to, io.MultiReader(srcs...))
 }
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/js"
+)
+
+// Runs the checks subcommands, i.e. "checks update".
+func checksCmd(args []string) error {
+	if len(args) != 1 {
+		return app.Errorf("Usage: closurer -conf <file> checks update")
+	}
+
+	switch args[0] {
+	case "update":
+		return js.UpdateChecks()
+	}
+
+	return app.Errorf("checks command not supported: %s", args[0])
+}
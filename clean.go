@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/manifest"
+)
+
+// Saves the fingerprinted outputs of the current target in the build
+// history, removing the old ones if there's a retention policy.
+func updateHistory() error {
+	conf := config.Current()
+	filename := filepath.Join(conf.Build, config.HISTORY_NAME)
+
+	history, err := manifest.LoadHistory(filename)
+	if err != nil {
+		return err
+	}
+
+	for key, f := range fingerprinted {
+		history.Add(config.SelectedTarget, key, f)
+	}
+	fingerprinted = manifest.Mapping{}
+
+	if conf.Retention != nil {
+		if err := removeOldOutputs(history, conf.Retention.Keep); err != nil {
+			return err
+		}
+	}
+
+	return history.Save(filename)
+}
+
+// Removes the old fingerprinted outputs of the current target. Without
+// a retention policy only the last build is kept.
+func clean() error {
+	conf := config.Current()
+	filename := filepath.Join(conf.Build, config.HISTORY_NAME)
+
+	history, err := manifest.LoadHistory(filename)
+	if err != nil {
+		return err
+	}
+
+	keep := 1
+	if conf.Retention != nil {
+		keep = conf.Retention.Keep
+	}
+	if err := removeOldOutputs(history, keep); err != nil {
+		return err
+	}
+
+	return history.Save(filename)
+}
+
+func removeOldOutputs(history manifest.History, keep int) error {
+	for _, f := range history.Trim(config.SelectedTarget, keep) {
+		log.Println("Removing old output:", f)
+
+		// Remove the precompressed versions too
+		for _, name := range []string{f, f + ".gz", f + ".br"} {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				return app.Error(err)
+			}
+		}
+	}
+
+	return nil
+}
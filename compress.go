@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/output"
+)
+
+type reportLine struct {
+	File               string
+	Size, Gzip, Brotli int
+}
+
+// Writes the precompressed versions of the fingerprinted JS & CSS outputs
+// of the current target next to them, and prints the build report with
+// the sizes of all the outputs.
+func compressOutputs() error {
+	conf := config.Current()
+	gz := conf.Compress != nil && conf.Compress.Gzip == "true"
+	br := conf.Compress != nil && conf.Compress.Brotli == "true"
+
+	report := []*reportLine{}
+	for _, key := range outputs.Keys() {
+		filename := outputs[key]
+
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return app.Error(err)
+		}
+		line := &reportLine{File: filename, Size: len(content)}
+
+		_, hashed := fingerprinted[key]
+		ext := filepath.Ext(filename)
+		if !hashed || (ext != ".js" && ext != ".css") {
+			report = append(report, line)
+			continue
+		}
+
+		if gz {
+			compressed, err := gzipContent(content)
+			if err != nil {
+				return err
+			}
+			if err := output.WriteFile(filename+".gz", compressed); err != nil {
+				return err
+			}
+			line.Gzip = len(compressed)
+		}
+
+		if br {
+			compressed, err := brotliContent(filename)
+			if err != nil {
+				return err
+			}
+			if err := output.WriteFile(filename+".br", compressed); err != nil {
+				return err
+			}
+			line.Brotli = len(compressed)
+		}
+
+		report = append(report, line)
+	}
+
+	printReport(report)
+
+	return nil
+}
+
+func gzipContent(content []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	w, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if err != nil {
+		return nil, app.Error(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, app.Error(err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, app.Error(err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// There's no brotli encoder in the standard library, use the
+// command line tool instead.
+func brotliContent(filename string) ([]byte, error) {
+	if _, err := exec.LookPath("brotli"); err != nil {
+		return nil, app.Errorf("brotli compression needs the brotli command: %s", err)
+	}
+
+	cmd := exec.Command("brotli", "--best", "--stdout", filename)
+	cmd.Stderr = os.Stderr
+
+	compressed, err := cmd.Output()
+	if err != nil {
+		return nil, app.Errorf("exec error: %s", err)
+	}
+
+	return compressed, nil
+}
+
+func printReport(report []*reportLine) {
+	fmt.Println("Build report for target", config.SelectedTarget)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Size\tGzip\tBrotli\t\tFile")
+	for _, line := range report {
+		fmt.Fprintf(w, "%s\t%s\t%s\t\t%s\n", formatSize(line.Size),
+			formatSize(line.Gzip), formatSize(line.Brotli), line.File)
+	}
+	w.Flush()
+}
+
+func formatSize(size int) string {
+	if size == 0 {
+		return "-"
+	}
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/1024)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+var flagNameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// Skips the validation of the check names while the
+// list is being updated.
+var IgnoreChecks bool
+
+// Diagnostic groups of the compiler, used when the list hasn't
+// been updated from its --help output.
+var DefaultChecks = []string{
+	"accessControls",
+	"ambiguousFunctionDecl",
+	"checkDebuggerStatement",
+	"checkRegExp",
+	"checkTypes",
+	"checkVars",
+	"const",
+	"constantProperty",
+	"deprecated",
+	"duplicateMessage",
+	"es5Strict",
+	"externsValidation",
+	"fileoverviewTags",
+	"globalThis",
+	"internetExplorerChecks",
+	"invalidCasts",
+	"missingProperties",
+	"nonStandardJsDocs",
+	"strictModuleDepCheck",
+	"suspiciousCode",
+	"typeInvalidation",
+	"undefinedNames",
+	"undefinedVars",
+	"unknownDefines",
+	"uselessCode",
+	"visibility",
+}
+
+// Flags managed by closurer that can't be passed directly.
+var reservedFlags = map[string]bool{
+	"js":             true,
+	"js_output_file": true,
+	"externs":        true,
+}
+
+// Returns the diagnostic groups accepted by the compiler, reading them
+// from the list of the build folder if it was updated.
+func KnownChecks() (map[string]bool, error) {
+	names := DefaultChecks
+
+	f, err := os.Open(filepath.Join(globalConf.Build, CHECKS_NAME))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, app.Error(err)
+	} else if err == nil {
+		defer f.Close()
+
+		names = []string{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				names = append(names, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, app.Error(err)
+		}
+	}
+
+	checks := map[string]bool{}
+	for _, name := range names {
+		checks[name] = true
+	}
+	return checks, nil
+}
+
+func validChecks(checks map[string]bool, lst []*CheckNode) error {
+	for _, check := range lst {
+		if !checks[check.Name] {
+			return app.Errorf("Illegal check: %s", check.Name)
+		}
+	}
+
+	return nil
+}
+
+func validFlags(lst []*FlagNode) error {
+	for _, f := range lst {
+		if !flagNameRe.MatchString(f.Name) {
+			return app.Errorf("Illegal compiler flag name: %s", f.Name)
+		}
+		if reservedFlags[f.Name] {
+			return app.Errorf("Compiler flag managed by closurer: %s", f.Name)
+		}
+	}
+
+	return nil
+}
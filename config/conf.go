@@ -1,41 +1,45 @@
 package config
 
 import (
-	"encoding/xml"
-	"log"
 	"os"
 	"strings"
-	"time"
+	"text/template"
 
 	"github.com/ernestokarim/closurer/app"
 )
 
 var (
-	globalConf       *Config
-	lastModification time.Time
+	globalConf        *Config
+	lastModifications modifications
 )
 
-func Load() error {
-	if globalConf != nil && !NoCache {
-		info, err := os.Lstat(ConfPath)
-		if err != nil {
-			return app.Error(err)
-		}
+// Input and output languages of the JS compiler.
+var languageModes = map[string]bool{
+	"ECMASCRIPT3":        true,
+	"ECMASCRIPT5":        true,
+	"ECMASCRIPT5_STRICT": true,
+	"ECMASCRIPT6":        true,
+	"ECMASCRIPT6_STRICT": true,
+	"ECMASCRIPT_2015":    true,
+	"ECMASCRIPT_2016":    true,
+	"ECMASCRIPT_2017":    true,
+	"ECMASCRIPT_2018":    true,
+	"ECMASCRIPT_2019":    true,
+	"ECMASCRIPT_2020":    true,
+	"ECMASCRIPT_2021":    true,
+	"ECMASCRIPT_NEXT":    true,
+	"STABLE":             true,
+}
 
-		if info.ModTime() == lastModification {
-			return nil
-		}
+func Load() error {
+	if globalConf != nil && !NoCache && !lastModifications.changed() {
+		return nil
 	}
 
-	f, err := os.Open(ConfPath)
+	mods := modifications{}
+	conf, err := readConfig(ConfPath, mods)
 	if err != nil {
-		return app.Error(err)
-	}
-	defer f.Close()
-
-	conf := new(Config)
-	if err := xml.NewDecoder(f).Decode(&conf); err != nil {
-		return app.Error(err)
+		return err
 	}
 
 	// Assign it before validating, because we need it to
@@ -45,14 +49,37 @@ func Load() error {
 	if err := conf.validate(); err != nil {
 		return err
 	}
+	lastModifications = mods
+
+	return nil
+}
 
-	info, err := os.Lstat(ConfPath)
+// Reads the config with its includes, but without validating
+// it, to diagnose the broken ones.
+func Read() (*Config, error) {
+	conf, err := readConfig(ConfPath, modifications{})
 	if err != nil {
-		return app.Error(err)
+		return nil, err
+	}
+	if err := conf.applyToolchain(); err != nil {
+		return nil, err
 	}
-	lastModification = info.ModTime()
 
-	return nil
+	return conf, nil
+}
+
+// Reads the config validating only the toolchain, to install
+// it before the rest of the paths exist.
+func ReadToolchain() (*ToolchainNode, error) {
+	conf, err := Read()
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.validToolchain(); err != nil {
+		return nil, err
+	}
+
+	return conf.Toolchain, nil
 }
 
 func Current() *Config {
@@ -60,6 +87,11 @@ func Current() *Config {
 }
 
 func (c *Config) validate() error {
+	// The command line has priority over the config
+	if c.Port != "" && !flagPassed("port") {
+		Port = c.Port
+	}
+
 	// Library & compiler paths
 	if c.Js != nil {
 		if c.Js.Root == "" {
@@ -70,18 +102,21 @@ func (c *Config) validate() error {
 			return app.Errorf("formatting mode not allowed: %s", c.Js.Formatting)
 		}
 
-		if c.Js.SideEffects != "" && c.Js.SideEffects != "true" {
-			return app.Errorf("boolean value not allowed: %s", c.Js.SideEffects)
+		if err := validBool(c.Js.SideEffects); err != nil {
+			return err
 		}
 
-		if c.Js.Language != "" {
-			modes := map[string]bool{
-				"ECMASCRIPT3":        true,
-				"ECMASCRIPT5":        true,
-				"ECMASCRIPT5_STRICT": true,
-			}
-			if _, ok := modes[c.Js.Language]; !ok {
-				return app.Errorf("language mode not allowed: %s", c.Js.Language)
+		if c.Js.Language != "" && !languageModes[c.Js.Language] {
+			return app.Errorf("language mode not allowed: %s", c.Js.Language)
+		}
+
+		// Fail fast instead of inside the compiler
+		for _, extern := range c.Js.Externs {
+			if _, err := os.Stat(extern.File); err != nil {
+				if os.IsNotExist(err) {
+					return app.Errorf("extern file not found: %s", extern.File)
+				}
+				return app.Error(err)
 			}
 		}
 
@@ -89,10 +124,12 @@ func (c *Config) validate() error {
 		if len(c.Js.Targets) == 0 {
 			return app.Errorf("No target provided for JS code")
 		}
+		targets := []inheritable{}
 		for _, t := range c.Js.Targets {
-			if err := t.ApplyInherits(); err != nil {
-				return err
-			}
+			targets = append(targets, t)
+		}
+		if err := applyInherits(targets); err != nil {
+			return err
 		}
 
 		// Check compilation mode and warnings level
@@ -115,27 +152,76 @@ func (c *Config) validate() error {
 			if _, ok := levels[t.Level]; !ok {
 				return app.Errorf("Illegal warning level in target %s: %s", t.Name, t.Level)
 			}
-		}
 
-		// Check that the command line target is in the config file
-		found := false
-		for _, name := range TargetList() {
-			for _, t := range c.Js.Targets {
-				if t.Name == name {
-					found = true
-					break
+			if err := validBool(t.Licenses); err != nil {
+				return err
+			}
+
+			// Output languages & polyfills
+			if t.LanguageOut != "" && !languageModes[t.LanguageOut] {
+				return app.Errorf("language mode not allowed: %s", t.LanguageOut)
+			}
+			if t.LegacyLanguageOut != "" && !languageModes[t.LegacyLanguageOut] {
+				return app.Errorf("language mode not allowed: %s", t.LegacyLanguageOut)
+			}
+			if t.LegacyLanguageOut != "" && t.LegacyOutput == "" {
+				return app.Errorf("Legacy language without a legacy output in target %s", t.Name)
+			}
+			if err := validBool(t.RewritePolyfills); err != nil {
+				return err
+			}
+			if err := validBool(t.IsolatePolyfills); err != nil {
+				return err
+			}
+
+			t.Wrapper = strings.TrimSpace(t.Wrapper)
+			if t.Wrapper != "" && !strings.Contains(t.Wrapper, "%output%") {
+				return app.Errorf("The wrapper of target %s needs a %%output%% placeholder",
+					t.Name)
+			}
+			// Library mode
+			if len(t.Exports) > 0 {
+				if t.Library == "" {
+					return app.Errorf("The library name of target %s is required", t.Name)
+				}
+				if t.Wrapper != "" {
+					return app.Errorf("Library target %s can't have a custom wrapper", t.Name)
+				}
+				for _, e := range t.Exports {
+					if e.Symbol == "" {
+						return app.Errorf("Exported symbol empty in target %s", t.Name)
+					}
 				}
 			}
-			if !found {
-				return app.Errorf("Target %s not found in the config file", name)
+
+			t.Banner = strings.TrimSpace(t.Banner)
+			if _, err := template.New("banner").Parse(t.Banner); err != nil {
+				return app.Errorf("Illegal banner in target %s: %s", t.Name, err)
 			}
 		}
 
 		// Validate the compilation checks
-		if c.Js.Checks != nil {
-			validChecks(c.Js.Checks.Errors)
-			validChecks(c.Js.Checks.Warnings)
-			validChecks(c.Js.Checks.Offs)
+		if c.Js.Checks != nil && !IgnoreChecks {
+			checks, err := KnownChecks()
+			if err != nil {
+				return err
+			}
+
+			for _, lst := range [][]*CheckNode{c.Js.Checks.Errors, c.Js.Checks.Warnings, c.Js.Checks.Offs} {
+				if err := validChecks(checks, lst); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Custom compiler flags
+		if err := validFlags(c.Js.Flags); err != nil {
+			return err
+		}
+		for _, t := range c.Js.Targets {
+			if err := validFlags(t.Flags); err != nil {
+				return err
+			}
 		}
 
 		// Check the prepend files
@@ -151,16 +237,103 @@ func (c *Config) validate() error {
 	if c.Build == "" {
 		return app.Errorf("The build folder is required")
 	}
+
+	for _, m := range c.Maps {
+		if m.File == "" {
+			return app.Errorf("The mapping file is required")
+		}
+
+		formats := map[string]bool{
+			"":           true,
+			"js":         true,
+			"json":       true,
+			"go":         true,
+			"properties": true,
+			"yaml":       true,
+		}
+		if _, ok := formats[m.Format]; !ok {
+			return app.Errorf("mapping format not allowed: %s", m.Format)
+		}
+		if m.Format == "go" && m.Package == "" {
+			return app.Errorf("The package name is required for the Go mapping: %s", m.File)
+		}
+
+		if err := validBool(m.Merge); err != nil {
+			return err
+		}
+
+		if err := validBool(m.Integrity); err != nil {
+			return err
+		}
+		if m.Integrity == "true" && m.Format != "" && m.Format != "js" && m.Format != "json" {
+			return app.Errorf("Integrity values are only allowed in js and json mappings: %s",
+				m.File)
+		}
+	}
+
+	pages := map[string]bool{}
+	for _, h := range c.Htmls {
+		if h.Input == "" {
+			return app.Errorf("The HTML input file is required")
+		}
+		if Build && h.Output == "" {
+			return app.Errorf("HTML page to build without an output file: %s", h.Input)
+		}
+		if Build && len(TargetList()) > 1 && !strings.Contains(h.Output, "{target}") {
+			return app.Errorf("HTML page built for several targets without {target} in the output file: %s", h.Output)
+		}
+
+		// The pages are the entry points, they can't be fingerprinted
+		if strings.Contains(h.Output, "{hash") || strings.Contains(h.Output, "{sha1") {
+			return app.Errorf("HTML page output file with a hash placeholder: %s", h.Output)
+		}
+
+		if pages[h.PageName()] {
+			return app.Errorf("HTML page repeated: %s", h.PageName())
+		}
+		pages[h.PageName()] = true
+	}
+
+	if c.Retention != nil && c.Retention.Keep < 1 {
+		return app.Errorf("At least one build should be kept: %d", c.Retention.Keep)
+	}
+
+	if c.Compress != nil {
+		if err := validBool(c.Compress.Gzip); err != nil {
+			return err
+		}
+		if err := validBool(c.Compress.Brotli); err != nil {
+			return err
+		}
+	}
+
+	if c.Hash != nil {
+		sizes := map[string]int{
+			"":       40,
+			"sha1":   40,
+			"sha256": 64,
+		}
+		size, ok := sizes[c.Hash.Algorithm]
+		if !ok {
+			return app.Errorf("hash algorithm not allowed: %s", c.Hash.Algorithm)
+		}
+		if c.Hash.Length < 0 || c.Hash.Length > size {
+			return app.Errorf("Illegal hash length: %d", c.Hash.Length)
+		}
+	}
+	if err := c.validToolchain(); err != nil {
+		return err
+	}
 	if c.Library != nil && c.Library.Root == "" {
 		return app.Errorf("The Closure Library path is required")
 	}
-	if c.Js != nil && c.Js.Compiler == "" {
+	if c.Js != nil && c.Js.Compiler == "" && c.Js.JarPath == "" {
 		return app.Errorf("The Closure Compiler path is required")
 	}
 
 	if c.Gss != nil {
 		// GSS compiler
-		if c.Gss.Compiler == "" {
+		if c.Gss.Compiler == "" && c.Gss.JarPath == "" {
 			return app.Errorf("The Closure Stylesheets path is required")
 		}
 
@@ -170,131 +343,327 @@ func (c *Config) validate() error {
 		}
 
 		// At least one input file should be provided
-		if len(c.Gss.Inputs) == 0 {
+		if len(c.Gss.Inputs) == 0 && len(c.Gss.Css) == 0 && len(c.Gss.Bundles) == 0 {
 			return app.Errorf("No inputs provided for GSS code")
 		}
 
-		// Compare JS targets and GSS targets
-		if c.Js != nil {
-			if len(c.Js.Targets) != len(c.Gss.Targets) {
-				return app.Errorf("Different number of targets provided for GSS & JS")
+		// GSS bundles
+		bundles := map[string]bool{}
+		for _, b := range c.Gss.Bundles {
+			if b.Name == "" {
+				return app.Errorf("The name of the GSS bundle is required")
 			}
-			for i, tjs := range c.Js.Targets {
-				tgss := c.Gss.Targets[i]
-				if tjs.Name != tgss.Name {
-					return app.Errorf("Targets with different name or order: %s != %s",
-						tjs.Name, tgss.Name)
-				}
+			// They would clash with the mapping keys of the RTL outputs
+			if b.Name == "rtl" || strings.HasPrefix(b.Name, "rtl-") {
+				return app.Errorf("Illegal GSS bundle name: %s", b.Name)
+			}
+			if bundles[b.Name] {
+				return app.Errorf("GSS bundle repeated: %s", b.Name)
+			}
+			bundles[b.Name] = true
 
-				// Rename property of the GSS target
-				if tgss.Rename != "true" && tgss.Rename != "false" && tgss.Rename != "" {
-					return app.Errorf("Illegal renaming policy value")
+			if len(b.Inputs) == 0 && len(b.Css) == 0 {
+				return app.Errorf("No inputs provided for GSS bundle %s", b.Name)
+			}
+			for _, css := range b.Css {
+				if css.File == "" {
+					return app.Errorf("CSS file empty in GSS bundle %s", b.Name)
 				}
+			}
+		}
 
-				// Apply the inherits option
-				if err := tgss.ApplyInherits(); err != nil {
-					return err
-				}
+		for _, css := range c.Gss.Css {
+			if css.File == "" {
+				return app.Errorf("CSS file empty")
+			}
+		}
 
-				// Check that the GSS defines don't have a value
-				for _, d := range tgss.Defines {
-					if d.Value != "" {
-						return app.Errorf("Define values in GSS should be empty")
-					}
+		// Apply the inherits option
+		targets := []inheritable{}
+		for _, t := range c.Gss.Targets {
+			targets = append(targets, t)
+		}
+		if err := applyInherits(targets); err != nil {
+			return err
+		}
+
+		for _, tgss := range c.Gss.Targets {
+			// Rename property of the GSS target
+			if tgss.Rename != "true" && tgss.Rename != "false" && tgss.Rename != "" {
+				return app.Errorf("Illegal renaming policy value")
+			}
+
+			// Check that the GSS defines don't have a value
+			for _, d := range tgss.Defines {
+				if d.Value != "" {
+					return app.Errorf("Define values in GSS should be empty")
 				}
 			}
+
+			// The plain CSS files are appended as they are
+			if tgss.Rtl == "true" && c.Gss.hasPlainCss() {
+				return app.Errorf("Plain CSS files can't be flipped for the RTL stylesheet of GSS target %s", tgss.Name)
+			}
+
+			if err := validGssOptions(tgss); err != nil {
+				return err
+			}
 		}
 	}
 
 	// Soy compiler
-	if c.Soy != nil && c.Soy.Root != "" && c.Soy.Compiler == "" {
+	if c.Soy != nil && c.Soy.Root != "" && c.Soy.Compiler == "" && c.Soy.JarPath == "" {
 		return app.Errorf("The Closure Templates path is required")
 	}
 
-	// Current targets in build mode
-	if c.Js != nil && c.Gss != nil {
-		for _, t := range TargetList() {
-			SelectTarget(t)
+	// JVM options of the compilers
+	for _, n := range c.javaNodes() {
+		for _, env := range n.Env {
+			if env.Name == "" || strings.Contains(env.Name, "=") {
+				return app.Errorf("Illegal environment variable name: %s", env.Name)
+			}
+		}
+	}
 
-			tjs := c.Js.CurTarget()
-			tgss := c.Gss.CurTarget()
+	// Defines of the command line, once the targets have
+	// inherited their own ones
+	if err := c.applyDefines(); err != nil {
+		return err
+	}
 
-			if tjs == nil || tgss == nil {
-				return app.Errorf("Target not found in the config: %s", t)
+	// GSS targets of the JS targets
+	if c.Js != nil {
+		for _, t := range c.Js.Targets {
+			if t.GssTarget == "" {
+				continue
+			}
+			if c.Gss == nil {
+				return app.Errorf("GSS target without GSS code in target %s: %s", t.Name, t.GssTarget)
+			}
+			if c.Gss.Target(t.GssTarget) == nil {
+				return app.Errorf("Unknown GSS target in target %s: %s", t.Name, t.GssTarget)
 			}
+		}
+	}
+	if c.Gss != nil && c.Gss.DefaultTarget != "" && c.Gss.Target(c.Gss.DefaultTarget) == nil {
+		return app.Errorf("Unknown default GSS target: %s", c.Gss.DefaultTarget)
+	}
 
-			if Build && IsTarget(tjs.Name) {
-				if tjs.Output == "" {
-					return app.Errorf("Target to build JS without an output file: %s",
-						tjs.Name)
-				}
-				if tgss != nil && tgss.Output == "" {
-					return app.Errorf("Target to build GSS without an output file: %s",
-						tjs.Name)
-				}
+	// Current targets in build mode; any of them can be missing
+	// to build only the JS or the GSS code.
+	for _, t := range TargetList() {
+		SelectTarget(t)
+
+		tjs := c.Js.CurTarget()
+		tgss := c.Gss.CurTarget()
+
+		if tjs == nil && tgss == nil {
+			return app.Errorf("Target not found in the config: %s", t)
+		}
+
+		if !Build {
+			continue
+		}
+
+		if tjs != nil && tjs.Output == "" {
+			return app.Errorf("Target to build JS without an output file: %s", t)
+		}
+
+		if tgss == nil {
+			continue
+		}
+		if len(c.Gss.Inputs) > 0 || len(c.Gss.Css) > 0 {
+			if tgss.Output == "" {
+				return app.Errorf("Target to build GSS without an output file: %s", t)
+			}
+			if tgss.Rtl == "true" && tgss.RtlOutput == "" {
+				return app.Errorf("Target to build RTL GSS without an output file: %s", t)
+			}
+		}
+		for _, b := range c.Gss.Bundles {
+			if b.Output == "" {
+				return app.Errorf("GSS bundle to build without an output file: %s",
+					b.Name)
+			}
+			if tgss.Rtl == "true" && b.RtlOutput == "" {
+				return app.Errorf("GSS bundle to build RTL without an output file: %s",
+					b.Name)
 			}
 		}
 	}
 
-	// Fix the compilers paths
+	return nil
+}
+
+// Checks the pinned tools and uses them for the compilers
+// and the library that don't have an explicit path.
+func (c *Config) validToolchain() error {
+	tc := c.Toolchain
+	if tc == nil {
+		return nil
+	}
+
+	// Allowed tools, and whether they've been pinned already
+	names := map[string]bool{
+		"compiler":    false,
+		"stylesheets": false,
+		"templates":   false,
+		"library":     false,
+	}
+	for _, t := range tc.Tools {
+		used, ok := names[t.Name]
+		if !ok {
+			return app.Errorf("tool not allowed: %s", t.Name)
+		}
+		if used {
+			return app.Errorf("tool pinned twice: %s", t.Name)
+		}
+		names[t.Name] = true
+
+		if t.Version == "" {
+			return app.Errorf("The version of the tool is required: %s", t.Name)
+		}
+		if t.Url == "" {
+			return app.Errorf("The url of the tool is required: %s", t.Name)
+		}
+		if len(t.Sha256) != 64 || strings.Trim(strings.ToLower(t.Sha256), "0123456789abcdef") != "" {
+			return app.Errorf("Illegal sha256 checksum of the tool %s: %s", t.Name, t.Sha256)
+		}
+	}
+
+	return c.applyToolchain()
+}
+
+// Uses the pinned tools for the compilers and the library that
+// don't have an explicit path.
+func (c *Config) applyToolchain() error {
+	tc := c.Toolchain
+	if tc == nil {
+		return nil
+	}
+
+	if tc.Cache == "" {
+		dir, err := confDir()
+		if err != nil {
+			return err
+		}
+		if tc.Cache, err = resolvePath(dir, DEFAULT_TOOLCHAIN_CACHE); err != nil {
+			return err
+		}
+	}
+	if Mirror != "" {
+		tc.Mirror = Mirror
+	}
+
+	if t := tc.Tool("compiler"); t != nil && c.Js != nil && c.Js.Compiler == "" && c.Js.JarPath == "" {
+		c.Js.Compiler = tc.Dir(t)
+	}
+	if t := tc.Tool("stylesheets"); t != nil && c.Gss != nil && c.Gss.Compiler == "" && c.Gss.JarPath == "" {
+		c.Gss.Compiler = tc.Dir(t)
+	}
+	if t := tc.Tool("templates"); t != nil && c.Soy != nil && c.Soy.Compiler == "" && c.Soy.JarPath == "" {
+		c.Soy.Compiler = tc.Dir(t)
+	}
+	if t := tc.Tool("library"); t != nil && c.Library != nil && c.Library.Root == "" {
+		c.Library.Root = tc.Dir(t)
+	}
+
+	return nil
+}
+
+// Returns the JVM options of the configured compilers.
+func (c *Config) javaNodes() []*JavaNode {
+	nodes := []*JavaNode{}
 	if c.Js != nil {
-		c.Js.Compiler = fixPath(c.Js.Compiler)
+		nodes = append(nodes, &c.Js.JavaNode)
 	}
 	if c.Gss != nil {
-		c.Gss.Compiler = fixPath(c.Gss.Compiler)
+		nodes = append(nodes, &c.Gss.JavaNode)
 	}
 	if c.Soy != nil {
-		c.Soy.Compiler = fixPath(c.Soy.Compiler)
-	}
-	if c.Library != nil {
-		c.Library.Root = fixPath(c.Library.Root)
+		nodes = append(nodes, &c.Soy.JavaNode)
 	}
+	return nodes
+}
 
+// Booleans are "true" or empty, and "false" too because the
+// JSON, YAML and TOML configs have native booleans.
+func validBool(value string) error {
+	if value != "" && value != "true" && value != "false" {
+		return app.Errorf("boolean value not allowed: %s", value)
+	}
 	return nil
 }
 
-// Replace the ~ with the correct folder path
-func fixPath(p string) string {
-	if !strings.Contains(p, "~") {
-		return p
+func validGssOptions(t *GssTargetNode) error {
+	if t.Vendor != "" {
+		vendors := map[string]bool{
+			"WEBKIT":    true,
+			"MOZILLA":   true,
+			"MICROSOFT": true,
+			"OPERA":     true,
+			"KONQUEROR": true,
+		}
+		if _, ok := vendors[t.Vendor]; !ok {
+			return app.Errorf("Illegal vendor in GSS target %s: %s", t.Name, t.Vendor)
+		}
 	}
 
-	user := os.Getenv("USER")
-	if user == "" {
-		user = os.Getenv("USERNAME")
+	if t.Orientation != "" {
+		orientations := map[string]bool{
+			"LTR":      true,
+			"RTL":      true,
+			"NOCHANGE": true,
+		}
+		if _, ok := orientations[t.Orientation]; !ok {
+			return app.Errorf("Illegal output orientation in GSS target %s: %s",
+				t.Name, t.Orientation)
+		}
 	}
-	if user == "" {
-		log.Fatal("Found ~ in a path, but USER nor USERNAME are exported in the env")
+
+	if err := validBool(t.PrettyPrint); err != nil {
+		return err
 	}
 
-	return strings.Replace(p, "~", "/home/"+user, -1)
-}
+	if err := validBool(t.Rtl); err != nil {
+		return err
+	}
+	if t.Rtl == "true" && t.Orientation == "RTL" {
+		return app.Errorf("GSS target %s is already RTL, it can't output a flipped version",
+			t.Name)
+	}
+
+	if t.RenamingMapFormat != "" {
+		formats := map[string]bool{
+			"CLOSURE_COMPILED":               true,
+			"CLOSURE_COMPILED_BY_WHOLE":      true,
+			"CLOSURE_COMPILED_SPLIT_HYPHENS": true,
+			"CLOSURE_UNCOMPILED":             true,
+			"JSON":                           true,
+			"PROPERTIES":                     true,
+			"JSCOMP_VARIABLE_MAP":            true,
+		}
+		if _, ok := formats[t.RenamingMapFormat]; !ok {
+			return app.Errorf("Illegal renaming map format in GSS target %s: %s",
+				t.Name, t.RenamingMapFormat)
+		}
+	}
+
+	// The map is only generated renaming the classes
+	if t.Rename == "true" && !t.JsRenamingMap() && t.RenamingMap == "" {
+		return app.Errorf("The renaming map file is required for the %s format in GSS target %s",
+			t.RenamingMapFormat, t.Name)
+	}
+
+	for _, p := range t.AllowedProps {
+		if p.Name == "" {
+			return app.Errorf("allowed property name empty in GSS target %s", t.Name)
+		}
+	}
 
-func validChecks(lst []*CheckNode) error {
-	for _, check := range lst {
-		checks := map[string]bool{
-			"ambiguousFunctionDecl":  true,
-			"checkRegExp":            true,
-			"checkTypes":             true,
-			"checkVars":              true,
-			"constantProperty":       true,
-			"deprecated":             true,
-			"fileoverviewTags":       true,
-			"internetExplorerChecks": true,
-			"invalidCasts":           true,
-			"missingProperties":      true,
-			"nonStandardJsDocs":      true,
-			"strictModuleDepCheck":   true,
-			"typeInvalidation":       true,
-			"undefinedNames":         true,
-			"undefinedVars":          true,
-			"unknownDefines":         true,
-			"uselessCode":            true,
-			"globalThis":             true,
-			"duplicateMessage":       true,
-		}
-		if _, ok := checks[check.Name]; !ok {
-			return app.Errorf("Illegal check: %s", check.Name)
+	for _, class := range t.ExcludedClasses {
+		if class.Name == "" {
+			return app.Errorf("excluded class name empty in GSS target %s", t.Name)
 		}
 	}
 
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/ernestokarim/closurer/app"
+
+	. "launchpad.net/gocheck"
+)
+
+type ConfSuite struct{}
+
+var _ = Suite(&ConfSuite{})
+
+func (s *ConfSuite) TestGssOnlyTarget(c *C) {
+	defer func(conf *Config, path, targets string) {
+		globalConf, ConfPath, BuildTargets = conf, path, targets
+	}(globalConf, ConfPath, BuildTargets)
+
+	dir := c.MkDir()
+	writeConfigs(c, dir, map[string]string{
+		"config.xml": `<application build="build">
+  <js root="client/js" compiler="closure-compiler">
+    <target name="dev" mode="RAW" level="QUIET"/>
+    <input file="main.js"/>
+  </js>
+  <gss compiler="closure-stylesheets" default-target="dev">
+    <target name="dev"/>
+    <target name="print"/>
+    <input file="main.gss"/>
+  </gss>
+  <library root="closure-library"/>
+</application>`,
+	})
+	ConfPath = filepath.Join(dir, "config.xml")
+	BuildTargets = "print"
+
+	conf, err := readConfig(ConfPath, modifications{})
+	c.Assert(err, IsNil)
+	globalConf = conf
+	c.Assert(conf.validate(), IsNil)
+
+	// The default GSS target is not used for the typos
+	BuildTargets = "prodution"
+	conf, err = readConfig(ConfPath, modifications{})
+	c.Assert(err, IsNil)
+	globalConf = conf
+	err = conf.validate()
+	c.Assert(err, NotNil)
+	c.Check(err.(*app.AppError).OriginalErr, ErrorMatches, "Target not found in the config: prodution")
+}
+
+func (s *ConfSuite) TestRenamingMapRequired(c *C) {
+	t := &GssTargetNode{Name: "dev", RenamingMapFormat: "JSON"}
+	c.Assert(validGssOptions(t), IsNil)
+
+	t.Rename = "true"
+	c.Assert(validGssOptions(t), NotNil)
+
+	t.RenamingMap = "build/renaming.json"
+	c.Assert(validGssOptions(t), IsNil)
+}
+
+func (s *ConfSuite) TestPlainCss(c *C) {
+	gss := &GssNode{}
+	c.Check(gss.hasPlainCss(), Equals, false)
+
+	gss.Bundles = []*BundleNode{{Name: "admin", Css: []*InputNode{{File: "admin.css"}}}}
+	c.Check(gss.hasPlainCss(), Equals, true)
+}
+
+func (s *ConfSuite) TestToolchain(c *C) {
+	sum := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	conf := &Config{
+		Js: &JsNode{},
+		Toolchain: &ToolchainNode{
+			Cache: "/cache",
+			Tools: []*ToolNode{{Name: "compiler", Version: "v1", Url: "http://example.com/c.zip", Sha256: sum}},
+		},
+	}
+	c.Assert(conf.validToolchain(), IsNil)
+	c.Check(conf.Js.Compiler, Equals, filepath.Join("/cache", "compiler", "v1"))
+
+	conf.Toolchain.Tools = append(conf.Toolchain.Tools, conf.Toolchain.Tools[0])
+	err := conf.validToolchain()
+	c.Assert(err, NotNil)
+	c.Check(err.(*app.AppError).OriginalErr, ErrorMatches, "tool pinned twice: compiler")
+}
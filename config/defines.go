@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// Defines of the command line: name=value for the JS code and
+// gss:NAME for the stylesheets. They can be repeated.
+type defineList []string
+
+func (l *defineList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *defineList) Set(value string) error {
+	name := strings.TrimPrefix(strings.SplitN(value, "=", 2)[0], "gss:")
+	if name == "" {
+		// Printed by the flag package, without the call stack
+		return fmt.Errorf("the name of the define is required: %s", value)
+	}
+
+	*l = append(*l, value)
+	return nil
+}
+
+// Overrides or adds the command line defines in all the targets. The GSS
+// defines with a false value are removed from them instead.
+func (c *Config) applyDefines() error {
+	for _, define := range Defines {
+		parts := strings.SplitN(define, "=", 2)
+		name, value := parts[0], ""
+		if len(parts) == 2 {
+			value = parts[1]
+		}
+
+		if strings.HasPrefix(name, "gss:") {
+			if c.Gss == nil {
+				return app.Errorf("GSS define without GSS code: %s", define)
+			}
+			if value != "" && value != "true" && value != "false" {
+				return app.Errorf("Define values in GSS should be empty: %s", define)
+			}
+
+			name = strings.TrimPrefix(name, "gss:")
+			for _, t := range c.Gss.Targets {
+				t.Defines = setDefine(t.Defines, name, "", value == "false")
+			}
+			continue
+		}
+
+		if c.Js == nil {
+			return app.Errorf("JS define without JS code: %s", define)
+		}
+		if len(parts) != 2 {
+			return app.Errorf("The value of the JS define is required: %s", define)
+		}
+		for _, t := range c.Js.Targets {
+			t.Defines = setDefine(t.Defines, name, value, false)
+		}
+	}
+
+	return nil
+}
+
+// Replaces the define in its place, or appends it if it's new.
+func setDefine(defines []*DefineNode, name, value string, remove bool) []*DefineNode {
+	result := []*DefineNode{}
+	found := false
+	for _, d := range defines {
+		if d.Name != name {
+			result = append(result, d)
+			continue
+		}
+
+		found = true
+		if !remove {
+			result = append(result, &DefineNode{Name: name, Value: value})
+		}
+	}
+
+	if !found && !remove {
+		result = append(result, &DefineNode{Name: name, Value: value})
+	}
+	return result
+}
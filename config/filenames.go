@@ -2,7 +2,34 @@ package config
 
 const (
 	JS_NAME           = "compiled.js"
+	JS_LEGACY_NAME    = "compiled-legacy.js"
 	DEPS_NAME         = "deps.js"
 	CSS_NAME          = "compiled.css"
+	CSS_RTL_NAME      = "compiled-rtl.css"
 	RENAMING_MAP_NAME = "renaming-map.js"
+
+	// Throwaway output of the pass that builds the renaming map
+	// shared by all the GSS bundles.
+	RENAMING_PASS_NAME = "renaming-pass.css"
+
+	// Fingerprinted outputs of the previous builds
+	HISTORY_NAME = "history.json"
+
+	// Exported symbols and compiler externs of the library targets
+	EXPORTS_NAME         = "exports.js"
+	LIBRARY_EXTERNS_NAME = "library-externs.js"
+
+	// Diagnostic groups read from the compiler --help output
+	CHECKS_NAME = "checks.txt"
+
+	// Shared folder of the installed tools
+	DEFAULT_TOOLCHAIN_CACHE = "~/.closurer/toolchain"
 )
+
+// Name of the compiled file of a GSS bundle in the build folder.
+func BundleCssName(bundle string, rtl bool) string {
+	if rtl {
+		return "compiled-" + bundle + "-rtl.css"
+	}
+	return "compiled-" + bundle + ".css"
+}
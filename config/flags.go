@@ -7,21 +7,41 @@ import (
 
 var (
 	// Command line flags
-	Build, NoCache, OutputCmd    bool
-	Port, ConfPath, BuildTargets string
+	Build, Clean, NoCache, OutputCmd bool
+	PrintConfig                      bool
+	Port, ConfPath, BuildTargets     string
+	Mirror                           string
 )
 
 var (
 	SelectedTarget string
+
+	// Defines of the command line
+	Defines defineList
 )
 
 func init() {
 	flag.BoolVar(&Build, "build", false, "build the compiled files only and exit")
+	flag.BoolVar(&Clean, "clean", false, "remove the old fingerprinted outputs and exit")
 	flag.BoolVar(&NoCache, "no-cache", false, "disables the files cache")
 	flag.BoolVar(&OutputCmd, "output-cmd", false, "output compiler issued command to a file")
+	flag.BoolVar(&PrintConfig, "print-config", false, "print the resolved config of the targets and exit")
 	flag.StringVar(&ConfPath, "conf", "", "the config file")
 	flag.StringVar(&Port, "port", ":9810", "the port where the server will be listening")
 	flag.StringVar(&BuildTargets, "targets", "", "the targets to run/compile, separated by colon")
+	flag.Var(&Defines, "define", "override a define in all the targets: name=value for JS, gss:NAME for GSS (repeatable)")
+	flag.StringVar(&Mirror, "mirror", "", "install the toolchain from this local folder instead of downloading it")
+}
+
+// Returns true if the flag was set in the command line.
+func flagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
 }
 
 func IsTarget(name string) bool {
@@ -34,6 +54,9 @@ func IsTarget(name string) bool {
 }
 
 func TargetList() []string {
+	if BuildTargets == "" {
+		return nil
+	}
 	return strings.Split(BuildTargets, ",")
 }
 
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/output"
+)
+
+// Returns the format of a config file from its extension.
+func Format(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xml":
+		return "xml", nil
+	case ".json":
+		return "json", nil
+	case ".yaml", ".yml":
+		return "yaml", nil
+	case ".toml":
+		return "toml", nil
+	}
+
+	return "", app.Errorf("config format not supported: %s", filename)
+}
+
+// Reads a config file of any of the formats, without validating it.
+// The unknown keys are reported but allowed, the old configs could
+// have them.
+func ReadFile(filename string) (*Config, error) {
+	return readFile(filename, false)
+}
+
+// Reads a config file like ReadFile, failing if it has unknown keys.
+func ReadFileStrict(filename string) (*Config, error) {
+	return readFile(filename, true)
+}
+
+func readFile(filename string, strict bool) (*Config, error) {
+	format, err := Format(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, app.Error(err)
+	}
+
+	var tree interface{}
+	switch format {
+	case "xml":
+		tree, err = parseXml(bytes.NewReader(content))
+	case "json":
+		err = json.Unmarshal(content, &tree)
+	case "yaml":
+		err = yaml.Unmarshal(content, &tree)
+	case "toml":
+		m := map[string]interface{}{}
+		_, err = toml.Decode(string(content), &m)
+		tree = m
+	}
+	if err != nil {
+		return nil, app.Errorf("cannot parse the config %s: %s", filename, err)
+	}
+
+	conf := new(Config)
+	unknown := []string{}
+	if err := decodeTree(tree, reflect.ValueOf(conf).Elem(), "", &unknown); err != nil {
+		return nil, err
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		if strict {
+			return nil, app.Errorf("unknown keys in the config %s: %s", filename,
+				strings.Join(unknown, ", "))
+		}
+		log.Printf("WARNING: unknown keys in the config %s: %s\n", filename,
+			strings.Join(unknown, ", "))
+	}
+
+	return conf, nil
+}
+
+// Writes the config in the format of the file extension.
+func WriteFile(filename string, conf *Config) error {
+	format, err := Format(filename)
+	if err != nil {
+		return err
+	}
+
+	obj := encodeTree(reflect.ValueOf(conf).Elem())
+
+	buf := bytes.NewBuffer(nil)
+	switch format {
+	case "xml":
+		writeXml(buf, "application", obj, "")
+
+	case "json":
+		content, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return app.Error(err)
+		}
+		buf.Write(content)
+		buf.WriteString("\n")
+
+	case "yaml":
+		content, err := yaml.Marshal(obj.yamlSlice())
+		if err != nil {
+			return app.Error(err)
+		}
+		buf.Write(content)
+
+	case "toml":
+		if err := toml.NewEncoder(buf).Encode(obj.Map()); err != nil {
+			return app.Error(err)
+		}
+	}
+
+	return output.Write(filename, buf)
+}
+
+// ==================================================================
+
+// Reads the XML elements as objects: the attributes and the children
+// are their keys, and the elements with only text are values.
+func parseXml(r io.Reader) (interface{}, error) {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return xmlElement(d, start)
+		}
+	}
+}
+
+func xmlElement(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	obj := map[string]interface{}{}
+	for _, attr := range start.Attr {
+		obj[attr.Name.Local] = attr.Value
+	}
+
+	text := bytes.NewBuffer(nil)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := xmlElement(d, t)
+			if err != nil {
+				return nil, err
+			}
+			lst, _ := obj[t.Name.Local].([]interface{})
+			obj[t.Name.Local] = append(lst, child)
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			if len(obj) == 0 {
+				return text.String(), nil
+			}
+			return obj, nil
+		}
+	}
+}
+
+func writeXml(w *bytes.Buffer, name string, obj schemaObject, indent string) {
+	fmt.Fprintf(w, "%s<%s", indent, name)
+
+	children := schemaObject{}
+	for _, f := range obj {
+		if !f.Attr {
+			children = append(children, f)
+			continue
+		}
+		fmt.Fprintf(w, ` %s="`, f.Key)
+		xml.EscapeText(w, []byte(fmt.Sprint(f.Value)))
+		w.WriteString(`"`)
+	}
+
+	if len(children) == 0 {
+		w.WriteString("/>\n")
+		return
+	}
+	w.WriteString(">\n")
+
+	for _, f := range children {
+		switch v := f.Value.(type) {
+		case schemaObject:
+			writeXml(w, f.Key, v, indent+"  ")
+		case []schemaObject:
+			for _, item := range v {
+				writeXml(w, f.Key, item, indent+"  ")
+			}
+		default:
+			fmt.Fprintf(w, "%s  <%s>", indent, f.Key)
+			xml.EscapeText(w, []byte(fmt.Sprint(v)))
+			fmt.Fprintf(w, "</%s>\n", f.Key)
+		}
+	}
+
+	fmt.Fprintf(w, "%s</%s>\n", indent, name)
+}
+
+// ==================================================================
+
+// Encodes the object with its keys in the order of the schema.
+func (obj schemaObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, f := range obj {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+func (obj schemaObject) yamlSlice() yaml.MapSlice {
+	m := yaml.MapSlice{}
+	for _, f := range obj {
+		value := f.Value
+		switch v := f.Value.(type) {
+		case schemaObject:
+			value = v.yamlSlice()
+		case []schemaObject:
+			lst := []yaml.MapSlice{}
+			for _, item := range v {
+				lst = append(lst, item.yamlSlice())
+			}
+			value = lst
+		}
+		m = append(m, yaml.MapItem{Key: f.Key, Value: value})
+	}
+	return m
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+
+	"github.com/ernestokarim/closurer/app"
+
+	. "launchpad.net/gocheck"
+)
+
+type FormatSuite struct{}
+
+var _ = Suite(&FormatSuite{})
+
+var formatConfigs = map[string]string{
+	"config.xml": `<application build="build">
+  <hash length="8"/>
+  <js root="client/js" compiler="~/closure-compiler" jvm-flags="-Xmx2g">
+    <target name="dev" mode="RAW" level="VERBOSE">
+      <define name="goog.DEBUG" value="true"/>
+      <wrapper>(function(){%output%})();</wrapper>
+    </target>
+    <input file="client/js/main.js"/>
+  </js>
+</application>`,
+
+	"config.json": `{
+  "build": "build",
+  "hash": {"length": 8},
+  "js": {
+    "root": "client/js",
+    "compiler": "~/closure-compiler",
+    "jvm-flags": "-Xmx2g",
+    "target": [{
+      "name": "dev", "mode": "RAW", "level": "VERBOSE",
+      "define": [{"name": "goog.DEBUG", "value": true}],
+      "wrapper": "(function(){%output%})();"
+    }],
+    "input": {"file": "client/js/main.js"}
+  }
+}`,
+
+	"config.yaml": `build: build
+hash:
+  length: 8
+js:
+  root: client/js
+  compiler: ~/closure-compiler
+  jvm-flags: -Xmx2g
+  target:
+  - name: dev
+    mode: RAW
+    level: VERBOSE
+    define:
+    - name: goog.DEBUG
+      value: true
+    wrapper: (function(){%output%})();
+  input:
+  - file: client/js/main.js
+`,
+
+	"config.toml": `build = "build"
+
+[hash]
+length = 8
+
+[js]
+root = "client/js"
+compiler = "~/closure-compiler"
+jvm-flags = "-Xmx2g"
+
+[[js.target]]
+name = "dev"
+mode = "RAW"
+level = "VERBOSE"
+wrapper = "(function(){%output%})();"
+
+[[js.target.define]]
+name = "goog.DEBUG"
+value = true
+
+[[js.input]]
+file = "client/js/main.js"
+`,
+}
+
+func (s *FormatSuite) TestFormats(c *C) {
+	dir := c.MkDir()
+
+	var expected *Config
+	for name, content := range formatConfigs {
+		filename := filepath.Join(dir, name)
+		c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+
+		conf, err := ReadFile(filename)
+		c.Assert(err, IsNil)
+		c.Assert(conf.Js, NotNil)
+		c.Check(conf.Js.JvmFlags, Equals, "-Xmx2g")
+		c.Check(conf.Hash.Length, Equals, 8)
+		c.Check(conf.Js.Targets[0].Defines[0].Value, Equals, "true")
+
+		if expected == nil {
+			expected = conf
+		}
+		c.Check(conf, DeepEquals, expected)
+	}
+}
+
+func (s *FormatSuite) TestConvert(c *C) {
+	dir := c.MkDir()
+	src := filepath.Join(dir, "config.xml")
+	c.Assert(ioutil.WriteFile(src, []byte(formatConfigs["config.xml"]), 0644), IsNil)
+
+	conf, err := ReadFile(src)
+	c.Assert(err, IsNil)
+
+	for _, name := range []string{"out.json", "out.yaml", "out.toml", "out.xml"} {
+		filename := filepath.Join(dir, name)
+		c.Assert(WriteFile(filename, conf), IsNil)
+
+		converted, err := ReadFile(filename)
+		c.Assert(err, IsNil)
+		c.Check(converted, DeepEquals, conf)
+	}
+}
+
+func (s *FormatSuite) TestUnknownKeys(c *C) {
+	unknown := []string{}
+	tree := map[string]interface{}{
+		"build": "build",
+		"js": map[string]interface{}{
+			"root":   "client/js",
+			"roots":  "typo",
+			"target": []interface{}{map[string]interface{}{"name": "dev", "mod": "RAW"}},
+		},
+	}
+
+	conf := new(Config)
+	c.Assert(decodeTree(tree, reflect.ValueOf(conf).Elem(), "", &unknown), IsNil)
+	c.Check(conf.Js.Root, Equals, "client/js")
+	c.Check(unknown, HasLen, 2)
+	c.Check(contains(unknown, "js.roots"), Equals, true)
+	c.Check(contains(unknown, "js.target[0].mod"), Equals, true)
+}
+
+func (s *FormatSuite) TestStrict(c *C) {
+	dir := c.MkDir()
+	filename := filepath.Join(dir, "config.json")
+	content := `{"build": "build", "js": {"root": "client/js", "roots": "typo"}}`
+	c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+
+	_, err := ReadFile(filename)
+	c.Assert(err, IsNil)
+
+	_, err = ReadFileStrict(filename)
+	c.Assert(err, NotNil)
+	c.Check(err.(*app.AppError).OriginalErr, ErrorMatches, "unknown keys in the config .*: js.roots")
+}
+
+func contains(lst []string, s string) bool {
+	for _, item := range lst {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *FormatSuite) TestNativeBooleans(c *C) {
+	defer func(conf *Config) { globalConf = conf }(globalConf)
+
+	dir := c.MkDir()
+	filename := filepath.Join(dir, "config.json")
+	content := `{
+  "build": "build",
+  "js": {
+    "root": "client/js",
+    "compiler": "closure-compiler",
+    "side-effects": false,
+    "target": [{"name": "dev", "mode": "RAW", "level": "QUIET", "licenses": false}],
+    "input": {"file": "main.js"}
+  },
+  "compress": {"gzip": true, "brotli": false},
+  "library": {"root": "closure-library"}
+}`
+	c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+
+	conf, err := ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Check(conf.Js.Targets[0].Licenses, Equals, "false")
+
+	globalConf = conf
+	c.Assert(conf.validate(), IsNil)
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// Modification times of the files that make up the config: the main
+// one, its includes and the local overlay. The missing ones have a zero
+// time so they're loaded when they're created.
+type modifications map[string]time.Time
+
+func (m modifications) add(filename string) error {
+	info, err := os.Stat(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return app.Error(err)
+	}
+
+	if err == nil {
+		m[filename] = info.ModTime()
+	} else {
+		m[filename] = time.Time{}
+	}
+	return nil
+}
+
+// Returns true if any of the files changed since they were loaded.
+func (m modifications) changed() bool {
+	for filename, t := range m {
+		info, err := os.Stat(filename)
+		if err != nil {
+			if !t.IsZero() {
+				return true
+			}
+			continue
+		}
+		if !info.ModTime().Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Name of the per-developer overlay of a config file:
+// config.local.xml for config.xml.
+func LocalPath(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + ".local" + ext
+}
+
+// Reads the config file with its includes and the local overlay on top.
+func readConfig(filename string, mods modifications) (*Config, error) {
+	conf, err := readIncludes(filename, mods, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	local := LocalPath(filename)
+	if err := mods.add(local); err != nil {
+		return nil, err
+	}
+	if !mods[local].IsZero() {
+		overlay, err := readIncludes(local, mods, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		mergeConfig(conf, overlay)
+
+		log.Printf("Local overrides loaded from %s\n", local)
+	}
+
+	return conf, nil
+}
+
+// Reads the config file merging its settings over the ones of the files
+// it includes. The paths of each file, including the ones of its includes,
+// are relative to the file.
+func readIncludes(filename string, mods modifications, visiting map[string]bool) (*Config, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, app.Error(err)
+	}
+	if visiting[abs] {
+		return nil, app.Errorf("Config included recursively: %s", filename)
+	}
+	visiting[abs] = true
+	defer delete(visiting, abs)
+
+	if err := mods.add(filename); err != nil {
+		return nil, err
+	}
+	conf, err := ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := interpolate(reflect.ValueOf(conf).Elem()); err != nil {
+		return nil, err
+	}
+	if err := conf.resolvePaths(filepath.Dir(abs)); err != nil {
+		return nil, err
+	}
+
+	merged := new(Config)
+	for _, include := range conf.Includes {
+		if include.File == "" {
+			return nil, app.Errorf("The file of the include is required: %s", filename)
+		}
+
+		name, err := resolvePath(filepath.Dir(abs), include.File)
+		if err != nil {
+			return nil, err
+		}
+
+		included, err := readIncludes(name, mods, visiting)
+		if err != nil {
+			return nil, err
+		}
+		mergeConfig(merged, included)
+	}
+	conf.Includes = nil
+	mergeConfig(merged, conf)
+
+	return merged, nil
+}
+
+// Merges the overlay over the config:
+//   - the values set in the overlay replace the old ones
+//   - the nodes are merged recursively
+//   - the items of the lists with the same name (or file, or path if
+//     they don't have a name) are merged, and the new ones appended.
+func mergeConfig(conf, overlay *Config) {
+	mergeValue(reflect.ValueOf(conf).Elem(), reflect.ValueOf(overlay).Elem())
+}
+
+func mergeValue(v, overlay reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			mergeValue(v.Field(i), overlay.Field(i))
+		}
+
+	case reflect.Ptr:
+		if overlay.IsNil() {
+			return
+		}
+		if v.IsNil() {
+			v.Set(overlay)
+			return
+		}
+		mergeValue(v.Elem(), overlay.Elem())
+
+	case reflect.Slice:
+		for i := 0; i < overlay.Len(); i++ {
+			item := overlay.Index(i)
+			if old := findItem(v, item); old.IsValid() {
+				mergeValue(old.Elem(), item.Elem())
+			} else {
+				v.Set(reflect.Append(v, item))
+			}
+		}
+
+	default:
+		if !overlay.IsZero() {
+			v.Set(overlay)
+		}
+	}
+}
+
+// Returns the item of the list with the same identity, if any.
+func findItem(lst, item reflect.Value) reflect.Value {
+	for _, key := range []string{"Name", "File", "Path"} {
+		id := item.Elem().FieldByName(key)
+		if !id.IsValid() {
+			continue
+		}
+		if id.String() == "" {
+			break
+		}
+
+		for i := 0; i < lst.Len(); i++ {
+			if lst.Index(i).Elem().FieldByName(key).String() == id.String() {
+				return lst.Index(i)
+			}
+		}
+		break
+	}
+
+	return reflect.Value{}
+}
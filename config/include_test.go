@@ -0,0 +1,128 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ernestokarim/closurer/app"
+
+	. "launchpad.net/gocheck"
+)
+
+type IncludeSuite struct{}
+
+var _ = Suite(&IncludeSuite{})
+
+func writeConfigs(c *C, dir string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		c.Assert(os.MkdirAll(filepath.Dir(filename), 0755), IsNil)
+		c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+	}
+}
+
+func (s *IncludeSuite) TestIncludesAndOverlay(c *C) {
+	dir := c.MkDir()
+	writeConfigs(c, dir, map[string]string{
+		"shared/closure.yaml": `
+js:
+  compiler: /opt/closure-compiler
+  target:
+  - name: dev
+    mode: RAW
+    level: VERBOSE
+    define:
+    - {name: goog.DEBUG, value: "true"}
+  input:
+  - file: shared.js
+`,
+		"config.xml": `<application build="build">
+  <include file="shared/closure.yaml"/>
+  <js root="client/js">
+    <target name="dev" level="QUIET"/>
+    <input file="main.js"/>
+  </js>
+</application>`,
+		"config.local.xml": `<application port=":8080">
+  <js compiler="~/closure-compiler">
+    <target name="dev">
+      <define name="app.USER" value="me"/>
+    </target>
+  </js>
+</application>`,
+	})
+
+	mods := modifications{}
+	conf, err := readConfig(filepath.Join(dir, "config.xml"), mods)
+	c.Assert(err, IsNil)
+
+	home, err := os.UserHomeDir()
+	c.Assert(err, IsNil)
+
+	c.Check(conf.Build, Equals, filepath.Join(dir, "build"))
+	c.Check(conf.Port, Equals, ":8080")
+	c.Check(conf.Includes, HasLen, 0)
+	c.Check(conf.Js.Root, Equals, filepath.Join(dir, "client/js"))
+	c.Check(conf.Js.Compiler, Equals, filepath.Join(home, "closure-compiler"))
+	c.Check(conf.Js.Inputs, DeepEquals, []*InputNode{{File: "shared.js"}, {File: "main.js"}})
+
+	c.Assert(conf.Js.Targets, HasLen, 1)
+	t := conf.Js.Targets[0]
+	c.Check(t.Mode, Equals, "RAW")
+	c.Check(t.Level, Equals, "QUIET")
+	c.Check(t.Defines, DeepEquals, []*DefineNode{
+		{Name: "goog.DEBUG", Value: "true"},
+		{Name: "app.USER", Value: "me"},
+	})
+
+	// All the files are watched
+	c.Check(mods, HasLen, 3)
+	c.Check(mods.changed(), Equals, false)
+
+	future := time.Now().Add(time.Hour)
+	c.Assert(os.Chtimes(filepath.Join(dir, "config.local.xml"), future, future), IsNil)
+	c.Check(mods.changed(), Equals, true)
+}
+
+func (s *IncludeSuite) TestIncludedPaths(c *C) {
+	dir := c.MkDir()
+	writeConfigs(c, dir, map[string]string{
+		"shared/closure.xml": `<application>
+  <js compiler="closure" jar="closure/compiler.jar">
+    <extern file="externs/api.js"/>
+  </js>
+</application>`,
+		"app/config.xml": `<application build="build">
+  <include file="../shared/closure.xml"/>
+  <js root="client/js"/>
+</application>`,
+	})
+
+	conf, err := readConfig(filepath.Join(dir, "app", "config.xml"), modifications{})
+	c.Assert(err, IsNil)
+
+	c.Check(conf.Build, Equals, filepath.Join(dir, "app", "build"))
+	c.Check(conf.Js.Root, Equals, filepath.Join(dir, "app", "client/js"))
+	c.Check(conf.Js.Compiler, Equals, filepath.Join(dir, "shared", "closure"))
+	c.Check(conf.Js.JarPath, Equals, filepath.Join(dir, "shared", "closure/compiler.jar"))
+	c.Check(conf.Js.Externs, DeepEquals, []*ExternNode{{File: filepath.Join(dir, "shared", "externs/api.js")}})
+}
+
+func (s *IncludeSuite) TestRecursiveInclude(c *C) {
+	dir := c.MkDir()
+	writeConfigs(c, dir, map[string]string{
+		"a.xml": `<application><include file="b.xml"/></application>`,
+		"b.xml": `<application><include file="a.xml"/></application>`,
+	})
+
+	_, err := readConfig(filepath.Join(dir, "a.xml"), modifications{})
+	c.Assert(err, NotNil)
+	c.Check(err.(*app.AppError).OriginalErr, ErrorMatches, "Config included recursively: .*a.xml")
+}
+
+func (s *IncludeSuite) TestLocalPath(c *C) {
+	c.Check(LocalPath("config.xml"), Equals, "config.local.xml")
+	c.Check(LocalPath("conf/closurer.yaml"), Equals, "conf/closurer.local.yaml")
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// Target of the config that can inherit the settings of another one.
+type inheritable interface {
+	targetName() string
+	parentName() string
+}
+
+func (t *JsTargetNode) targetName() string  { return t.Name }
+func (t *JsTargetNode) parentName() string  { return t.Inherits }
+func (t *GssTargetNode) targetName() string { return t.Name }
+func (t *GssTargetNode) parentName() string { return t.Inherits }
+
+// Resolves the inheritance chains of the targets, in any order. Each
+// target takes the fields it leaves empty from its parent once the
+// parent has been resolved itself.
+func applyInherits(targets []inheritable) error {
+	byName := map[string]inheritable{}
+	for _, t := range targets {
+		if t.targetName() == "" {
+			return app.Errorf("The name of the target is required")
+		}
+		if _, ok := byName[t.targetName()]; ok {
+			return app.Errorf("Duplicated target: %s", t.targetName())
+		}
+		byName[t.targetName()] = t
+	}
+
+	resolved := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var resolve func(t inheritable) error
+	resolve = func(t inheritable) error {
+		name := t.targetName()
+		if resolved[name] || t.parentName() == "" {
+			return nil
+		}
+		if visiting[name] {
+			return app.Errorf("Inheritance cycle in the target: %s", name)
+		}
+		visiting[name] = true
+
+		parent, ok := byName[t.parentName()]
+		if !ok {
+			return app.Errorf("Target %s inherits from an unknown target: %s",
+				name, t.parentName())
+		}
+		if err := resolve(parent); err != nil {
+			return err
+		}
+		inherit(reflect.ValueOf(t).Elem(), reflect.ValueOf(parent).Elem())
+
+		resolved[name] = true
+		return nil
+	}
+
+	for _, t := range targets {
+		if err := resolve(t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Copies the empty fields of the child from the parent. Lists of
+// name-value pairs (defines, flags) are merged by name with copies of
+// the parent ones; the rest of the lists are copied only if the child
+// has none.
+func inherit(child, parent reflect.Value) {
+	for i := 0; i < child.NumField(); i++ {
+		name := child.Type().Field(i).Name
+		if name == "Name" || name == "Inherits" {
+			continue
+		}
+
+		field, value := child.Field(i), parent.Field(i)
+		if field.Kind() == reflect.Slice && isPairList(field) {
+			field.Set(mergePairs(field, value))
+		} else if isZero(field) {
+			field.Set(value)
+		}
+	}
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// Returns true if the elements of the list are
+// pointers to structs with a Name and a Value.
+func isPairList(lst reflect.Value) bool {
+	elem := lst.Type().Elem()
+	if elem.Kind() != reflect.Ptr || elem.Elem().Kind() != reflect.Struct {
+		return false
+	}
+
+	_, name := elem.Elem().FieldByName("Name")
+	_, value := elem.Elem().FieldByName("Value")
+	return name && value
+}
+
+// Appends copies of the parent pairs whose name is not in the child.
+func mergePairs(child, parent reflect.Value) reflect.Value {
+	names := map[string]bool{}
+	for i := 0; i < child.Len(); i++ {
+		names[child.Index(i).Elem().FieldByName("Name").String()] = true
+	}
+
+	merged := child
+	for i := 0; i < parent.Len(); i++ {
+		item := parent.Index(i).Elem()
+		if names[item.FieldByName("Name").String()] {
+			continue
+		}
+
+		clone := reflect.New(item.Type())
+		clone.Elem().Set(item)
+		merged = reflect.Append(merged, clone)
+	}
+	return merged
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/ernestokarim/closurer/app"
+
+	. "launchpad.net/gocheck"
+)
+
+// Hook up gocheck into the gotest runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type InheritSuite struct{}
+
+var _ = Suite(&InheritSuite{})
+
+func (s *InheritSuite) TestChain(c *C) {
+	prod := &JsTargetNode{Name: "prod", Inherits: "staging", Output: "prod.js"}
+	staging := &JsTargetNode{
+		Name:     "staging",
+		Inherits: "dev",
+		Mode:     "ADVANCED",
+		Defines:  []*DefineNode{{Name: "goog.DEBUG", Value: "false"}},
+	}
+	dev := &JsTargetNode{
+		Name:     "dev",
+		Mode:     "RAW",
+		Level:    "VERBOSE",
+		Licenses: "true",
+		Defines: []*DefineNode{
+			{Name: "goog.DEBUG", Value: "true"},
+			{Name: "goog.LOCALE", Value: "es"},
+		},
+		Flags:   []*FlagNode{{Name: "jscomp_off", Value: "checkVars"}},
+		Exports: []*ExportNode{{Symbol: "app.main"}},
+	}
+
+	// Parents can be declared after their children
+	c.Assert(applyInherits([]inheritable{prod, staging, dev}), IsNil)
+
+	c.Check(prod.Mode, Equals, "ADVANCED")
+	c.Check(prod.Level, Equals, "VERBOSE")
+	c.Check(prod.Output, Equals, "prod.js")
+	c.Check(prod.Licenses, Equals, "true")
+	c.Check(prod.Inherits, Equals, "staging")
+	c.Check(prod.Defines, DeepEquals, []*DefineNode{
+		{Name: "goog.DEBUG", Value: "false"},
+		{Name: "goog.LOCALE", Value: "es"},
+	})
+	c.Check(prod.Flags, DeepEquals, dev.Flags)
+	c.Check(prod.Exports, DeepEquals, dev.Exports)
+
+	// The parent keeps its own values, the pairs are copied
+	c.Check(dev.Defines[0].Value, Equals, "true")
+	c.Check(prod.Flags[0] != dev.Flags[0], Equals, true)
+	c.Check(staging.Flags[0] != dev.Flags[0], Equals, true)
+}
+
+func (s *InheritSuite) TestErrors(c *C) {
+	a := &GssTargetNode{Name: "a", Inherits: "b"}
+	b := &GssTargetNode{Name: "b", Inherits: "a"}
+	err := applyInherits([]inheritable{a, b})
+	c.Assert(err, NotNil)
+	c.Check(err.(*app.AppError).OriginalErr, ErrorMatches, "Inheritance cycle in the target: a")
+
+	orphan := &GssTargetNode{Name: "orphan", Inherits: "unknown"}
+	err = applyInherits([]inheritable{orphan})
+	c.Assert(err, NotNil)
+	c.Check(err.(*app.AppError).OriginalErr, ErrorMatches,
+		"Target orphan inherits from an unknown target: unknown")
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// ${NAME} or ${NAME:-default}; $${ escapes the interpolation.
+var envRe = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-)([^}]*))?\}`)
+
+// Replaces the environment variables in all the values of the config.
+func interpolate(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := interpolate(v.Field(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return interpolate(v.Elem())
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := interpolate(v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.String:
+		s, err := expandEnv(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	}
+
+	return nil
+}
+
+func expandEnv(s string) (string, error) {
+	var err error
+	s = envRe.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		m := envRe.FindStringSubmatch(match)
+		if value, ok := os.LookupEnv(m[1]); ok && (value != "" || m[2] == "") {
+			return value
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+
+		if err == nil {
+			err = app.Errorf("Environment variable not defined in the config: %s", m[1])
+		}
+		return ""
+	})
+	return s, err
+}
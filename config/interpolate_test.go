@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"reflect"
+
+	"github.com/ernestokarim/closurer/app"
+
+	. "launchpad.net/gocheck"
+)
+
+type InterpolateSuite struct{}
+
+var _ = Suite(&InterpolateSuite{})
+
+func (s *InterpolateSuite) TestEnv(c *C) {
+	os.Setenv("CLOSURER_TEST_VERSION", "1.2.0")
+	os.Setenv("CLOSURER_TEST_EMPTY", "")
+	defer os.Unsetenv("CLOSURER_TEST_VERSION")
+	defer os.Unsetenv("CLOSURER_TEST_EMPTY")
+
+	conf := &Config{
+		Build: "build-${CLOSURER_TEST_VERSION}",
+		Js: &JsNode{
+			Targets: []*JsTargetNode{
+				{
+					Name: "prod",
+					Defines: []*DefineNode{
+						{Name: "app.VERSION", Value: "${CLOSURER_TEST_VERSION}"},
+						{Name: "app.MODE", Value: "${CLOSURER_TEST_EMPTY:-prod}"},
+						{Name: "app.TEMPLATE", Value: "$${CLOSURER_TEST_VERSION}"},
+					},
+				},
+			},
+		},
+	}
+	c.Assert(interpolate(reflect.ValueOf(conf).Elem()), IsNil)
+
+	c.Assert(conf.Build, Equals, "build-1.2.0")
+	defines := conf.Js.Targets[0].Defines
+	c.Assert(defines[0].Value, Equals, "1.2.0")
+	c.Assert(defines[1].Value, Equals, "prod")
+	c.Assert(defines[2].Value, Equals, "${CLOSURER_TEST_VERSION}")
+
+	_, err := expandEnv("${CLOSURER_TEST_MISSING}")
+	c.Assert(err.(*app.AppError).OriginalErr, ErrorMatches,
+		"Environment variable not defined in the config: CLOSURER_TEST_MISSING")
+}
+
+func (s *InterpolateSuite) TestDefines(c *C) {
+	defer func() { Defines = nil }()
+	Defines = nil
+	c.Assert(Defines.Set("app.VERSION=1.2.0"), IsNil)
+	c.Assert(Defines.Set("goog.DEBUG=false"), IsNil)
+	c.Assert(Defines.Set("gss:MOBILE"), IsNil)
+	c.Assert(Defines.Set("gss:IE=false"), IsNil)
+	c.Assert(Defines.Set("gss:=true"), NotNil)
+
+	conf := &Config{
+		Js: &JsNode{
+			Targets: []*JsTargetNode{
+				{Name: "prod", Defines: []*DefineNode{{Name: "goog.DEBUG", Value: "true"}}},
+			},
+		},
+		Gss: &GssNode{
+			Targets: []*GssTargetNode{
+				{Name: "prod", Defines: []*DefineNode{{Name: "IE"}}},
+			},
+		},
+	}
+	c.Assert(conf.applyDefines(), IsNil)
+
+	c.Assert(conf.Js.Targets[0].Defines, DeepEquals, []*DefineNode{
+		{Name: "goog.DEBUG", Value: "false"},
+		{Name: "app.VERSION", Value: "1.2.0"},
+	})
+	c.Assert(conf.Gss.Targets[0].Defines, DeepEquals, []*DefineNode{{Name: "MOBILE"}})
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// Returns the folder of the config file, the base of its relative paths.
+func confDir() (string, error) {
+	dir, err := filepath.Abs(filepath.Dir(ConfPath))
+	if err != nil {
+		return "", app.Error(err)
+	}
+	return dir, nil
+}
+
+// Returns the absolute path of a path of the config: a ~ at the start is
+// the home folder of the user, and the relative paths are relative to dir.
+func resolvePath(dir, p string) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", app.Errorf("Found ~ in the path %s, but the home folder is unknown: %s", p, err)
+		}
+		p = filepath.Join(home, p[1:])
+	}
+
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Clean(p), nil
+}
+
+// Returns the path relative to the folder of the config file, as the
+// mappings published them when they were relative to the current folder.
+// The paths that only share the root of the filesystem stay absolute.
+func RelPath(p string) string {
+	dir, err := confDir()
+	if err != nil {
+		return p
+	}
+
+	if topFolder(dir) != topFolder(p) {
+		return p
+	}
+
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return p
+	}
+	return rel
+}
+
+// Returns the first folder of an absolute path, /home for /home/user.
+func topFolder(p string) string {
+	p = strings.TrimPrefix(filepath.Clean(p), filepath.VolumeName(p))
+	p = strings.TrimLeft(p, string(filepath.Separator))
+	return strings.SplitN(p, string(filepath.Separator), 2)[0]
+}
+
+// Resolves all the paths of a config file against its folder, so the rest
+// of the code can compare and join them without caring about the current
+// folder. The outputs of the JS targets and the JS inputs and prepends are
+// relative to the JS root instead.
+func (c *Config) resolvePaths(dir string) error {
+	paths := []*string{&c.Build}
+	for _, n := range c.Ignores {
+		paths = append(paths, &n.Path)
+	}
+	for _, m := range c.Maps {
+		paths = append(paths, &m.File)
+	}
+	for _, h := range c.Htmls {
+		paths = append(paths, &h.Input, &h.Output, &h.Root, &h.Critical)
+	}
+
+	if c.Js != nil {
+		paths = append(paths, &c.Js.Root, &c.Js.Compiler)
+		for _, extern := range c.Js.Externs {
+			paths = append(paths, &extern.File)
+		}
+	}
+
+	if c.Gss != nil {
+		paths = append(paths, &c.Gss.Compiler)
+		paths = append(paths, inputPaths(c.Gss.Inputs, c.Gss.Css)...)
+		for _, t := range c.Gss.Targets {
+			paths = append(paths, &t.Output, &t.RtlOutput, &t.Assets, &t.RenamingMap)
+		}
+		for _, b := range c.Gss.Bundles {
+			paths = append(paths, &b.Output, &b.RtlOutput)
+			paths = append(paths, inputPaths(b.Inputs, b.Css)...)
+		}
+	}
+
+	if c.Soy != nil {
+		paths = append(paths, &c.Soy.Root, &c.Soy.Compiler)
+	}
+	if c.Library != nil {
+		paths = append(paths, &c.Library.Root)
+	}
+
+	if tc := c.Toolchain; tc != nil {
+		paths = append(paths, &tc.Cache)
+		if !strings.Contains(tc.Mirror, "://") {
+			paths = append(paths, &tc.Mirror)
+		}
+	}
+
+	// The JVM without a folder is looked up in the PATH
+	for _, n := range c.javaNodes() {
+		paths = append(paths, &n.JarPath)
+		if strings.ContainsAny(n.Java, `/\~`) {
+			paths = append(paths, &n.Java)
+		}
+	}
+
+	for _, p := range paths {
+		var err error
+		if *p, err = resolvePath(dir, *p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func inputPaths(lists ...[]*InputNode) []*string {
+	paths := []*string{}
+	for _, lst := range lists {
+		for _, input := range lst {
+			paths = append(paths, &input.File)
+		}
+	}
+	return paths
+}
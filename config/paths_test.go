@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	. "launchpad.net/gocheck"
+)
+
+type PathsSuite struct{}
+
+var _ = Suite(&PathsSuite{})
+
+func (s *PathsSuite) TestResolvePath(c *C) {
+	home, err := os.UserHomeDir()
+	c.Assert(err, IsNil)
+
+	tests := map[string]string{
+		"":                   "",
+		"~":                  home,
+		"~/closure-compiler": filepath.Join(home, "closure-compiler"),
+		"client/js":          "/app/client/js",
+		"../library":         "/library",
+		"/opt/closure":       "/opt/closure",
+		"static/~old":        "/app/static/~old",
+	}
+	for p, expected := range tests {
+		resolved, err := resolvePath("/app", p)
+		c.Assert(err, IsNil)
+		c.Assert(resolved, Equals, expected)
+	}
+}
+
+func (s *PathsSuite) TestResolvePaths(c *C) {
+	defer func(old string) { ConfPath = old }(ConfPath)
+	dir := c.MkDir()
+	ConfPath = filepath.Join(dir, "config.xml")
+
+	conf := &Config{
+		Build: "build",
+		Js: &JsNode{
+			JavaNode: JavaNode{Java: "java"},
+			Root:     "client/js",
+			Targets:  []*JsTargetNode{{Name: "prod", Output: "app.js"}},
+		},
+		Gss: &GssNode{
+			Inputs:  []*InputNode{{File: "client/css/app.gss"}},
+			Targets: []*GssTargetNode{{Name: "prod", Output: "static/app.css"}},
+		},
+		Toolchain: &ToolchainNode{Mirror: "https://mirror.example.com"},
+	}
+	c.Assert(conf.resolvePaths(dir), IsNil)
+
+	c.Assert(conf.Build, Equals, filepath.Join(dir, "build"))
+	c.Assert(conf.Js.Root, Equals, filepath.Join(dir, "client/js"))
+	c.Assert(conf.Js.Java, Equals, "java")
+	c.Assert(conf.Js.Targets[0].Output, Equals, "app.js")
+	c.Assert(conf.Gss.Inputs[0].File, Equals, filepath.Join(dir, "client/css/app.gss"))
+	c.Assert(conf.Gss.Targets[0].Output, Equals, filepath.Join(dir, "static/app.css"))
+	c.Assert(conf.Toolchain.Mirror, Equals, "https://mirror.example.com")
+
+	c.Assert(RelPath(filepath.Join(dir, "static/app.css")), Equals, "static/app.css")
+	c.Assert(RelPath(filepath.Join(filepath.Dir(dir), "shared/app.css")), Equals, "../shared/app.css")
+	c.Assert(RelPath("/elsewhere/app.css"), Equals, "/elsewhere/app.css")
+}
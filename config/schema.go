@@ -0,0 +1,290 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// The XML tags of the config structs are the schema of every format: the
+// attributes and the child elements are keys of the same object, and
+// the repeated elements are lists.
+
+// Key of the schema of a struct field.
+type schemaKey struct {
+	name  string
+	attr  bool
+	index []int
+}
+
+// Returns the keys of a config struct, including the ones of the
+// embedded structs.
+func schemaKeys(t reflect.Type) []*schemaKey {
+	keys := []*schemaKey{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			for _, k := range schemaKeys(f.Type) {
+				k.index = append([]int{i}, k.index...)
+				keys = append(keys, k)
+			}
+			continue
+		}
+
+		parts := strings.Split(f.Tag.Get("xml"), ",")
+		if parts[0] == "" || parts[0] == "-" {
+			continue
+		}
+
+		k := &schemaKey{name: parts[0], index: []int{i}}
+		for _, p := range parts[1:] {
+			if p == "attr" {
+				k.attr = true
+			}
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Fills the struct with the generic tree of a config file, as decoded
+// from any of the formats, collecting the keys that aren't in the schema.
+func decodeTree(tree interface{}, v reflect.Value, path string, unknown *[]string) error {
+	obj, err := object(tree, path)
+	if err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+	for _, k := range schemaKeys(v.Type()) {
+		known[k.name] = true
+
+		value, ok := obj[k.name]
+		if !ok {
+			continue
+		}
+		if err := decodeValue(value, v.FieldByIndex(k.index), keyPath(path, k.name), unknown); err != nil {
+			return err
+		}
+	}
+
+	for key := range obj {
+		if !known[key] {
+			*unknown = append(*unknown, keyPath(path, key))
+		}
+	}
+
+	return nil
+}
+
+func decodeValue(value interface{}, field reflect.Value, path string, unknown *[]string) error {
+	switch field.Kind() {
+	case reflect.String:
+		s, err := scalar(value, path)
+		if err != nil {
+			return err
+		}
+		field.SetString(s)
+
+	case reflect.Int:
+		s, err := scalar(value, path)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return app.Errorf("%s: integer expected: %s", path, s)
+		}
+		field.SetInt(int64(n))
+
+	case reflect.Ptr:
+		if lst, ok := list(value); ok {
+			if len(lst) != 1 {
+				return app.Errorf("%s: a single value expected", path)
+			}
+			value = lst[0]
+		}
+
+		node := reflect.New(field.Type().Elem())
+		if err := decodeTree(value, node.Elem(), path, unknown); err != nil {
+			return err
+		}
+		field.Set(node)
+
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Ptr {
+			return app.Errorf("%s: list kind not supported: %s", path, field.Type().Elem().Kind())
+		}
+
+		lst, ok := list(value)
+		if !ok {
+			lst = []interface{}{value}
+		}
+
+		items := reflect.MakeSlice(field.Type(), 0, len(lst))
+		for i, item := range lst {
+			node := reflect.New(field.Type().Elem().Elem())
+			if err := decodeTree(item, node.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown); err != nil {
+				return err
+			}
+			items = reflect.Append(items, node)
+		}
+		field.Set(items)
+
+	default:
+		return app.Errorf("%s: field kind not supported: %s", path, field.Kind())
+	}
+
+	return nil
+}
+
+// Normalizes the objects of the different decoders.
+func object(tree interface{}, path string) (map[string]interface{}, error) {
+	switch obj := tree.(type) {
+	case map[string]interface{}:
+		return obj, nil
+
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range obj {
+			m[fmt.Sprint(k)] = v
+		}
+		return m, nil
+
+	case string:
+		// Empty XML element
+		if strings.TrimSpace(obj) == "" {
+			return map[string]interface{}{}, nil
+		}
+
+	case nil:
+		return map[string]interface{}{}, nil
+	}
+
+	return nil, app.Errorf("%s: an object expected", path)
+}
+
+// Normalizes the lists of the different decoders.
+func list(value interface{}) ([]interface{}, bool) {
+	switch lst := value.(type) {
+	case []interface{}:
+		return lst, true
+
+	case []map[string]interface{}:
+		items := []interface{}{}
+		for _, item := range lst {
+			items = append(items, item)
+		}
+		return items, true
+	}
+
+	return nil, false
+}
+
+func scalar(value interface{}, path string) (string, error) {
+	// Text of an XML element
+	if lst, ok := value.([]interface{}); ok && len(lst) == 1 {
+		value = lst[0]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	}
+
+	return "", app.Errorf("%s: a value expected", path)
+}
+
+func keyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ==================================================================
+
+// Ordered object of the config, as the formats encode it.
+type schemaObject []*schemaField
+
+type schemaField struct {
+	Key   string
+	Attr  bool
+	Value interface{}
+}
+
+// Converts a config struct to an ordered tree, without the empty values.
+func encodeTree(v reflect.Value) schemaObject {
+	obj := schemaObject{}
+
+	for _, k := range schemaKeys(v.Type()) {
+		field := v.FieldByIndex(k.index)
+
+		var value interface{}
+		switch field.Kind() {
+		case reflect.String:
+			if field.Len() > 0 {
+				value = field.String()
+			}
+
+		case reflect.Int:
+			if field.Int() != 0 {
+				value = int(field.Int())
+			}
+
+		case reflect.Ptr:
+			if !field.IsNil() {
+				value = encodeTree(field.Elem())
+			}
+
+		case reflect.Slice:
+			if field.Len() > 0 {
+				lst := []schemaObject{}
+				for i := 0; i < field.Len(); i++ {
+					lst = append(lst, encodeTree(field.Index(i).Elem()))
+				}
+				value = lst
+			}
+		}
+
+		if value != nil {
+			obj = append(obj, &schemaField{Key: k.name, Attr: k.attr, Value: value})
+		}
+	}
+
+	return obj
+}
+
+// Converts the tree to the maps and lists of the encoders.
+func (obj schemaObject) Map() map[string]interface{} {
+	m := map[string]interface{}{}
+	for _, f := range obj {
+		switch v := f.Value.(type) {
+		case schemaObject:
+			m[f.Key] = v.Map()
+		case []schemaObject:
+			lst := []map[string]interface{}{}
+			for _, item := range v {
+				lst = append(lst, item.Map())
+			}
+			m[f.Key] = lst
+		default:
+			m[f.Key] = v
+		}
+	}
+	return m
+}
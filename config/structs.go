@@ -1,34 +1,60 @@
 package config
 
 import (
-	"github.com/ernestokarim/closurer/app"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
 type Config struct {
-	Build string `xml:"build,attr"`
-
-	Ignores []*IgnoreNode `xml:"ignore"`
-	Map     *MapNode      `xml:"map"`
-	Js      *JsNode       `xml:"js"`
-	Gss     *GssNode      `xml:"gss"`
-	Soy     *SoyNode      `xml:"soy"`
-	Library *LibraryNode  `xml:"library"`
+	Build string `xml:"build,attr,omitempty"`
+
+	// Server port, used if it's not passed in the command line.
+	Port string `xml:"port,attr,omitempty"`
+
+	// Files with shared settings, overridden by this one.
+	Includes []*IncludeNode `xml:"include,omitempty"`
+
+	Ignores   []*IgnoreNode  `xml:"ignore,omitempty"`
+	Maps      []*MapNode     `xml:"map,omitempty"`
+	Hash      *HashNode      `xml:"hash,omitempty"`
+	Retention *RetentionNode `xml:"retention,omitempty"`
+	Compress  *CompressNode  `xml:"compress,omitempty"`
+	Htmls     []*HtmlNode    `xml:"html,omitempty"`
+	Js        *JsNode        `xml:"js,omitempty"`
+	Gss       *GssNode       `xml:"gss,omitempty"`
+	Soy       *SoyNode       `xml:"soy,omitempty"`
+	Library   *LibraryNode   `xml:"library,omitempty"`
+	Toolchain *ToolchainNode `xml:"toolchain,omitempty"`
 }
 
 // ==================================================================
 
 type JsNode struct {
-	Root        string `xml:"root,attr"`
-	Compiler    string `xml:"compiler,attr"`
-	Language    string `xml:"language,attr"`
-	Formatting  string `xml:"formatting,attr"`
-	SideEffects string `xml:"side-effects,attr"`
+	JavaNode
+
+	Root        string `xml:"root,attr,omitempty"`
+	Compiler    string `xml:"compiler,attr,omitempty"`
+	Language    string `xml:"language,attr,omitempty"`
+	Formatting  string `xml:"formatting,attr,omitempty"`
+	SideEffects string `xml:"side-effects,attr,omitempty"`
+	Version     string `xml:"version,attr,omitempty"`
+
+	Checks   *ChecksNode     `xml:"checks,omitempty"`
+	Flags    []*FlagNode     `xml:"flag,omitempty"`
+	Targets  []*JsTargetNode `xml:"target,omitempty"`
+	Inputs   []*InputNode    `xml:"input,omitempty"`
+	Externs  []*ExternNode   `xml:"extern,omitempty"`
+	Prepends []*PrependNode  `xml:"prepend,omitempty"`
+}
 
-	Checks   *ChecksNode     `xml:"checks"`
-	Targets  []*JsTargetNode `xml:"target"`
-	Inputs   []*InputNode    `xml:"input"`
-	Externs  []*ExternNode   `xml:"extern"`
-	Prepends []*PrependNode  `xml:"prepend"`
+// Path of the Closure Compiler jar.
+func (n *JsNode) Jar() string {
+	if n.JarPath != "" {
+		return n.JarPath
+	}
+	return filepath.Join(n.Compiler, "build", "compiler.jar")
 }
 
 func (n *JsNode) CurTarget() *JsTargetNode {
@@ -46,89 +72,120 @@ func (n *JsNode) CurTarget() *JsTargetNode {
 
 // ==================================================================
 
+type IncludeNode struct {
+	File string `xml:"file,attr,omitempty"`
+}
+
+// ==================================================================
+
 type IgnoreNode struct {
-	Path string `xml:"path,attr"`
+	Path string `xml:"path,attr,omitempty"`
 }
 
 // ==================================================================
 
 type ChecksNode struct {
-	Errors   []*CheckNode `xml:"error"`
-	Warnings []*CheckNode `xml:"warning"`
-	Offs     []*CheckNode `xml:"off"`
+	Errors   []*CheckNode `xml:"error,omitempty"`
+	Warnings []*CheckNode `xml:"warning,omitempty"`
+	Offs     []*CheckNode `xml:"off,omitempty"`
 }
 
 // ==================================================================
 
 type CheckNode struct {
-	Name string `xml:"name,attr"`
+	Name string `xml:"name,attr,omitempty"`
 }
 
 // ==================================================================
 
 type JsTargetNode struct {
-	Name     string `xml:"name,attr"`
-	Mode     string `xml:"mode,attr"`
-	Level    string `xml:"level,attr"`
-	Output   string `xml:"output,attr"`
-	Inherits string `xml:"inherits,attr"`
-
-	Defines []*DefineNode `xml:"define"`
+	Name     string `xml:"name,attr,omitempty"`
+	Mode     string `xml:"mode,attr,omitempty"`
+	Level    string `xml:"level,attr,omitempty"`
+	Output   string `xml:"output,attr,omitempty"`
+	Inherits string `xml:"inherits,attr,omitempty"`
+
+	// GSS target compiled with this one, instead of the one
+	// with the same name.
+	GssTarget string `xml:"gss-target,attr,omitempty"`
+
+	// Move the @license comments of the inputs to the top of the output.
+	Licenses string `xml:"licenses,attr,omitempty"`
+
+	// Output wrapper with a %output% placeholder, and a banner with
+	// the {{.Version}}, {{.Commit}}, {{.Date}} and {{.Target}} variables.
+	Wrapper string `xml:"wrapper,omitempty"`
+	Banner  string `xml:"banner,omitempty"`
+
+	// Library mode: the exported symbols are published in a UMD bundle
+	// with the Library global name, and the externs for the consumers
+	// are written to ExternsOutput. All the outputs are relative to the
+	// JS root.
+	Library       string `xml:"library,attr,omitempty"`
+	ExternsOutput string `xml:"externs-output,attr,omitempty"`
+
+	// Language of the compiled code and polyfills injected for
+	// the features missing in it.
+	LanguageOut      string `xml:"language-out,attr,omitempty"`
+	RewritePolyfills string `xml:"rewrite-polyfills,attr,omitempty"`
+	IsolatePolyfills string `xml:"isolate-polyfills,attr,omitempty"`
+
+	// Differential loading: a second bundle for the legacy browsers,
+	// compiled to ECMASCRIPT5 by default, is written to LegacyOutput.
+	LegacyOutput      string `xml:"legacy-output,attr,omitempty"`
+	LegacyLanguageOut string `xml:"legacy-language-out,attr,omitempty"`
+
+	Defines []*DefineNode `xml:"define,omitempty"`
+	Exports []*ExportNode `xml:"export,omitempty"`
+	Flags   []*FlagNode   `xml:"flag,omitempty"`
 }
 
-func (t *JsTargetNode) ApplyInherits() error {
-	if t.Name == "" {
-		return app.Errorf("The name of the target is required")
-	}
-
-	if t.Inherits == "" {
-		return nil
+func (t *JsTargetNode) HasDefine(name string) bool {
+	for _, d := range t.Defines {
+		if d.Name == name {
+			return true
+		}
 	}
+	return false
+}
 
-	for _, parent := range globalConf.Js.Targets {
-		if parent.Name == t.Name {
-			return app.Errorf("Inherits should reference a previous target: %s", t.Name)
-		}
-		if parent.Name != t.Inherits {
-			continue
+func (t *JsTargetNode) HasFlag(name string) bool {
+	for _, f := range t.Flags {
+		if f.Name == name {
+			return true
 		}
+	}
+	return false
+}
 
-		if t.Mode == "" {
-			t.Mode = parent.Mode
-		}
-		if t.Level == "" {
-			t.Level = parent.Level
-		}
-		if t.Output == "" {
-			t.Output = parent.Output
-		}
+// ==================================================================
 
-		for _, d := range parent.Defines {
-			if !t.HasDefine(d.Name) {
-				t.Defines = append(t.Defines, d.Clone())
-			}
-		}
+// Symbol exported by a library target. By default it's published
+// with the last part of its name.
+type ExportNode struct {
+	Symbol string `xml:"symbol,attr,omitempty"`
+	As     string `xml:"as,attr,omitempty"`
+}
 
-		return nil
+// Returns the public name of the symbol.
+func (n *ExportNode) PublicName() string {
+	if n.As != "" {
+		return n.As
 	}
-
-	panic("not reached")
+	return n.Symbol[strings.LastIndex(n.Symbol, ".")+1:]
 }
 
-func (t *JsTargetNode) HasDefine(name string) bool {
-	for _, d := range t.Defines {
-		if d.Name == name {
-			return true
-		}
-	}
-	return false
+// Returns true if the symbol is a property of a prototype, exported
+// in the instances instead of the library object.
+func (n *ExportNode) IsProperty() bool {
+	return strings.Contains(n.Symbol, ".prototype.")
 }
 
 // ==================================================================
 
 type DefineNode struct {
-	Name  string `xml:"name,attr"`
-	Value string `xml:"value,attr"`
+	Name  string `xml:"name,attr,omitempty"`
+	Value string `xml:"value,attr,omitempty"`
 }
 
 func (d *DefineNode) Clone() *DefineNode {
@@ -140,90 +197,260 @@ func (d *DefineNode) Clone() *DefineNode {
 
 // ==================================================================
 
+// Compiler flag passed as is, without the dashes in the name.
+// Flags without value are passed alone.
+type FlagNode struct {
+	Name  string `xml:"name,attr,omitempty"`
+	Value string `xml:"value,attr,omitempty"`
+}
+
+func (f *FlagNode) Clone() *FlagNode {
+	return &FlagNode{
+		Name:  f.Name,
+		Value: f.Value,
+	}
+}
+
+func (f *FlagNode) Args() []string {
+	if f.Value == "" {
+		return []string{"--" + f.Name}
+	}
+	return []string{"--" + f.Name, f.Value}
+}
+
+// ==================================================================
+
+// Mapping of the outputs. The files are relative to the folder
+// of the config, or absolute if they're in another tree.
 type MapNode struct {
-	File string `xml:"file,attr"`
+	File    string `xml:"file,attr,omitempty"`
+	Format  string `xml:"format,attr,omitempty"`
+	Package string `xml:"package,attr,omitempty"`
+
+	// Keep the entries of other targets already present in the file.
+	Merge string `xml:"merge,attr,omitempty"`
+
+	// Write the Subresource Integrity value next to each URL.
+	Integrity string `xml:"integrity,attr,omitempty"`
+}
+
+// ==================================================================
+
+// Hash used for the {hash} placeholders of the output files.
+type HashNode struct {
+	Algorithm string `xml:"algorithm,attr,omitempty"`
+	Length    int    `xml:"length,attr,omitempty"`
+}
+
+// ==================================================================
+
+// Number of fingerprinted outputs of each target that are
+// kept when cleaning old builds.
+type RetentionNode struct {
+	Keep int `xml:"keep,attr,omitempty"`
+}
+
+// ==================================================================
+
+// Precompressed versions of the outputs written next to them.
+// Brotli needs the brotli command in the PATH.
+type CompressNode struct {
+	Gzip   string `xml:"gzip,attr,omitempty"`
+	Brotli string `xml:"brotli,attr,omitempty"`
+}
+
+// ==================================================================
+
+// HTML page rendered as a Go template with the URLs of the outputs.
+type HtmlNode struct {
+	Name  string `xml:"name,attr,omitempty"`
+	Input string `xml:"input,attr,omitempty"`
+
+	// The {target} placeholder is replaced with the name of the target,
+	// required to build several targets.
+	Output string `xml:"output,attr,omitempty"`
+
+	// The URLs are the paths relative to Root with the Prefix added. Without
+	// a root they're relative to the output page.
+	Root   string `xml:"root,attr,omitempty"`
+	Prefix string `xml:"prefix,attr,omitempty"`
+
+	// CSS file inlined in the page
+	Critical string `xml:"critical,attr,omitempty"`
+}
+
+// Returns the name of the page, by default the name of the input file.
+func (n *HtmlNode) PageName() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return filepath.Base(n.Input)
+}
+
+// Returns the output file of the page for the target.
+func (n *HtmlNode) TargetOutput(target string) string {
+	return strings.Replace(n.Output, "{target}", target, -1)
 }
 
 // ==================================================================
 
 type InputNode struct {
-	File string `xml:"file,attr"`
+	File string `xml:"file,attr,omitempty"`
 }
 
 // ==================================================================
 
 type ExternNode struct {
-	File string `xml:"file,attr"`
+	File string `xml:"file,attr,omitempty"`
 }
 
 // ==================================================================
 
 type GssNode struct {
-	Compiler string `xml:"compiler,attr"`
+	JavaNode
 
-	Targets []*GssTargetNode `xml:"target"`
-	Funcs   []*FuncNode      `xml:"func"`
-	Inputs  []*InputNode     `xml:"input"`
+	Compiler string `xml:"compiler,attr,omitempty"`
+
+	// Target used by the JS targets without a GSS target
+	// of their own.
+	DefaultTarget string `xml:"default-target,attr,omitempty"`
+
+	Targets []*GssTargetNode `xml:"target,omitempty"`
+	Funcs   []*FuncNode      `xml:"func,omitempty"`
+	Inputs  []*InputNode     `xml:"input,omitempty"`
+	Bundles []*BundleNode    `xml:"bundle,omitempty"`
+
+	// Plain CSS files appended to the compiled stylesheet.
+	Css []*InputNode `xml:"css,omitempty"`
 }
 
-func (n *GssNode) CurTarget() *GssTargetNode {
-	if n == nil {
-		return nil
+// Path of the Closure Stylesheets jar.
+func (n *GssNode) Jar() string {
+	if n.JarPath != "" {
+		return n.JarPath
 	}
+	return filepath.Join(n.Compiler, "build", "closure-stylesheets.jar")
+}
 
-	for _, t := range n.Targets {
-		if t.Name == SelectedTarget {
-			return t
+// Returns the inputs of the main stylesheet and all the bundles,
+// without repetitions.
+func (n *GssNode) AllInputs() []*InputNode {
+	seen := map[string]bool{}
+	inputs := []*InputNode{}
+
+	add := func(lst []*InputNode) {
+		for _, input := range lst {
+			if !seen[input.File] {
+				seen[input.File] = true
+				inputs = append(inputs, input)
+			}
 		}
 	}
-	return nil
-}
 
-// ==================================================================
+	add(n.Inputs)
+	for _, b := range n.Bundles {
+		add(b.Inputs)
+	}
 
-type GssTargetNode struct {
-	Name     string `xml:"name,attr"`
-	Rename   string `xml:"rename,attr"`
-	Output   string `xml:"output,attr"`
-	Inherits string `xml:"inherits,attr"`
+	return inputs
+}
 
-	Defines []*DefineNode `xml:"define"`
+// Returns true if the main stylesheet or any of the bundles
+// have plain CSS files.
+func (n *GssNode) hasPlainCss() bool {
+	if len(n.Css) > 0 {
+		return true
+	}
+	for _, b := range n.Bundles {
+		if len(b.Css) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
-func (t *GssTargetNode) ApplyInherits() error {
-	if t.Name == "" {
-		return app.Errorf("The name of the target is required")
+func (n *GssNode) Bundle(name string) *BundleNode {
+	for _, b := range n.Bundles {
+		if b.Name == name {
+			return b
+		}
 	}
+	return nil
+}
 
-	if t.Inherits == "" {
+// Returns the GSS target of the selected one: the target referenced by
+// the JS target, the GSS target with the same name or the default one.
+// The default is only used for the JS targets, not for unknown names.
+func (n *GssNode) CurTarget() *GssTargetNode {
+	if n == nil {
 		return nil
 	}
 
-	for _, parent := range globalConf.Gss.Targets {
-		if parent.Name == t.Name {
-			return app.Errorf("Inherits should reference a previous target: %s", t.Name)
-		}
-		if parent.Name != t.Inherits {
-			continue
-		}
+	var tjs *JsTargetNode
+	if globalConf != nil {
+		tjs = globalConf.Js.CurTarget()
+	}
 
-		if t.Rename == "" {
-			t.Rename = parent.Rename
-		}
-		if t.Output == "" {
-			t.Output = parent.Output
-		}
+	name := SelectedTarget
+	if tjs != nil && tjs.GssTarget != "" {
+		name = tjs.GssTarget
+	}
 
-		for _, d := range parent.Defines {
-			if !t.HasDefine(d.Name) {
-				t.Defines = append(t.Defines, d.Clone())
-			}
-		}
+	if t := n.Target(name); t != nil {
+		return t
+	}
+	if tjs == nil {
+		return nil
+	}
+	return n.Target(n.DefaultTarget)
+}
 
+func (n *GssNode) Target(name string) *GssTargetNode {
+	if name == "" {
 		return nil
 	}
 
-	panic("not reached")
+	for _, t := range n.Targets {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// ==================================================================
+
+type GssTargetNode struct {
+	Name        string `xml:"name,attr,omitempty"`
+	Rename      string `xml:"rename,attr,omitempty"`
+	Output      string `xml:"output,attr,omitempty"`
+	Inherits    string `xml:"inherits,attr,omitempty"`
+	Vendor      string `xml:"vendor,attr,omitempty"`
+	Orientation string `xml:"orientation,attr,omitempty"`
+	PrettyPrint string `xml:"pretty-print,attr,omitempty"`
+
+	// Folder where the assets referenced from the stylesheets will be
+	// copied with their hash in the name.
+	Assets string `xml:"assets,attr,omitempty"`
+
+	// Compile a flipped RTL stylesheet too, next to the normal one.
+	Rtl       string `xml:"rtl,attr,omitempty"`
+	RtlOutput string `xml:"rtl-output,attr,omitempty"`
+
+	// Format of the renaming map. The CLOSURE_* formats are JS and can be
+	// fed to the JS compiler; the rest need an explicit RenamingMap file.
+	RenamingMapFormat string `xml:"renaming-map-format,attr,omitempty"`
+	RenamingMap       string `xml:"renaming-map,attr,omitempty"`
+
+	Defines         []*DefineNode   `xml:"define,omitempty"`
+	AllowedProps    []*PropertyNode `xml:"allowed-property,omitempty"`
+	ExcludedClasses []*ClassNode    `xml:"excluded-class,omitempty"`
+}
+
+// Returns true if the renaming map will be written in a format
+// the JS compiler can read.
+func (t *GssTargetNode) JsRenamingMap() bool {
+	return t.RenamingMapFormat == "" || strings.HasPrefix(t.RenamingMapFormat, "CLOSURE_")
 }
 
 func (t *GssTargetNode) HasDefine(name string) bool {
@@ -237,25 +464,135 @@ func (t *GssTargetNode) HasDefine(name string) bool {
 
 // ==================================================================
 
+// Independent stylesheet compiled with the same renaming map
+// of the main one.
+type BundleNode struct {
+	Name      string `xml:"name,attr,omitempty"`
+	Output    string `xml:"output,attr,omitempty"`
+	RtlOutput string `xml:"rtl-output,attr,omitempty"`
+
+	Inputs []*InputNode `xml:"input,omitempty"`
+	Css    []*InputNode `xml:"css,omitempty"`
+}
+
+// ==================================================================
+
 type SoyNode struct {
-	Root     string `xml:"root,attr"`
-	Compiler string `xml:"compiler,attr"`
+	JavaNode
+
+	Root     string `xml:"root,attr,omitempty"`
+	Compiler string `xml:"compiler,attr,omitempty"`
+}
+
+// Path of the Closure Templates jar.
+func (n *SoyNode) Jar() string {
+	if n.JarPath != "" {
+		return n.JarPath
+	}
+	return filepath.Join(n.Compiler, "build", "SoyToJsSrcCompiler.jar")
+}
+
+// ==================================================================
+
+// Options of the JVM that runs one of the compilers. The jar
+// overrides the build folder of the compiler checkout.
+type JavaNode struct {
+	JarPath  string `xml:"jar,attr,omitempty"`
+	Java     string `xml:"java,attr,omitempty"`
+	JvmFlags string `xml:"jvm-flags,attr,omitempty"`
+
+	Env []*EnvNode `xml:"env,omitempty"`
+}
+
+// Prepares the command that runs the jar with the JVM options.
+func (n *JavaNode) Command(jar string, args ...string) *exec.Cmd {
+	java := n.Java
+	if java == "" {
+		java = "java"
+	}
+
+	flags := append(strings.Fields(n.JvmFlags), "-jar", jar)
+	cmd := exec.Command(java, append(flags, args...)...)
+
+	if len(n.Env) > 0 {
+		cmd.Env = os.Environ()
+		for _, env := range n.Env {
+			cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+		}
+	}
+
+	return cmd
+}
+
+// ==================================================================
+
+type EnvNode struct {
+	Name  string `xml:"name,attr,omitempty"`
+	Value string `xml:"value,attr,omitempty"`
 }
 
 // ==================================================================
 
 type LibraryNode struct {
-	Root string `xml:"root,attr"`
+	Root string `xml:"root,attr,omitempty"`
+}
+
+// ==================================================================
+
+type ToolchainNode struct {
+	Cache  string `xml:"cache,attr,omitempty"`
+	Mirror string `xml:"mirror,attr,omitempty"`
+
+	Tools []*ToolNode `xml:"tool,omitempty"`
+}
+
+func (n *ToolchainNode) Tool(name string) *ToolNode {
+	if n == nil {
+		return nil
+	}
+
+	for _, t := range n.Tools {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Returns the folder where a version of the tool is installed.
+func (n *ToolchainNode) Dir(tool *ToolNode) string {
+	return filepath.Join(n.Cache, tool.Name, tool.Version)
+}
+
+// ==================================================================
+
+type ToolNode struct {
+	Name    string `xml:"name,attr,omitempty"`
+	Version string `xml:"version,attr,omitempty"`
+	Url     string `xml:"url,attr,omitempty"`
+	Sha256  string `xml:"sha256,attr,omitempty"`
 }
 
 // ==================================================================
 
 type FuncNode struct {
-	Name string `xml:"name,attr"`
+	Name string `xml:"name,attr,omitempty"`
+}
+
+// ==================================================================
+
+type PropertyNode struct {
+	Name string `xml:"name,attr,omitempty"`
+}
+
+// ==================================================================
+
+type ClassNode struct {
+	Name string `xml:"name,attr,omitempty"`
 }
 
 // ==================================================================
 
 type PrependNode struct {
-	File string `xml:"file,attr"`
+	File string `xml:"file,attr,omitempty"`
 }
@@ -0,0 +1,56 @@
+package config
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+type StructsSuite struct{}
+
+var _ = Suite(&StructsSuite{})
+
+func (s *StructsSuite) TestGssCurTarget(c *C) {
+	defer func(conf *Config, target string) {
+		globalConf, SelectedTarget = conf, target
+	}(globalConf, SelectedTarget)
+
+	globalConf = &Config{
+		Js: &JsNode{
+			Targets: []*JsTargetNode{
+				{Name: "dev"},
+				{Name: "prod"},
+				{Name: "prod-lite", GssTarget: "prod"},
+				{Name: "mobile"},
+			},
+		},
+		Gss: &GssNode{
+			DefaultTarget: "dev",
+			Targets: []*GssTargetNode{
+				{Name: "dev"},
+				{Name: "prod"},
+				{Name: "print"},
+			},
+		},
+	}
+
+	targets := map[string]string{
+		"prod":      "prod",
+		"prod-lite": "prod",
+		"print":     "print",
+		"mobile":    "dev",
+	}
+	for name, gss := range targets {
+		SelectTarget(name)
+		c.Check(globalConf.Gss.CurTarget().Name, Equals, gss)
+	}
+
+	// The default is not used for the unknown targets
+	SelectTarget("unknown")
+	c.Check(globalConf.Gss.CurTarget(), IsNil)
+
+	globalConf.Gss.DefaultTarget = ""
+	SelectTarget("mobile")
+	c.Check(globalConf.Gss.CurTarget(), IsNil)
+
+	var gss *GssNode
+	c.Check(gss.CurTarget(), IsNil)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+)
+
+// Runs the config subcommands, i.e. "config convert in.xml out.yaml".
+func configCmd(args []string) error {
+	if len(args) != 3 || args[0] != "convert" {
+		return app.Errorf("Usage: closurer config convert <input> <output>")
+	}
+
+	// The config is translated as it's written, without resolving the
+	// inherits or the paths; the unknown keys would be lost
+	conf, err := config.ReadFileStrict(args[1])
+	if err != nil {
+		return err
+	}
+	if err := config.WriteFile(args[2], conf); err != nil {
+		return err
+	}
+
+	log.Printf("Config converted to %s\n", args[2])
+
+	return nil
+}
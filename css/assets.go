@@ -0,0 +1,85 @@
+package css
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/digest"
+	"github.com/ernestokarim/closurer/output"
+)
+
+// Copies the assets referenced with relative url()s in the CSS code to the
+// assetsDir folder, with the hash of their content in the name. The
+// references are rewritten to point to the new files. cssDir is the folder
+// where the stylesheet will be served from.
+// It returns the new CSS code and a map of original to fingerprinted files.
+func Fingerprint(src, cssDir, assetsDir string) (string, map[string]string, error) {
+	if err := os.MkdirAll(assetsDir, 0755); err != nil {
+		return "", nil, app.Error(err)
+	}
+
+	assets := map[string]string{}
+
+	var err error
+	result := urlRe.ReplaceAllStringFunc(src, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		m := urlRe.FindStringSubmatch(match)
+		if !IsRelativeUrl(m[2]) {
+			return match
+		}
+
+		// Keep the query & fragment parts (fonts usually have them)
+		u, suffix := m[2], ""
+		if i := strings.IndexAny(u, "?#"); i != -1 {
+			u, suffix = u[:i], u[i:]
+		}
+
+		asset := filepath.Join(cssDir, filepath.FromSlash(u))
+		hashed, ok := assets[asset]
+		if !ok {
+			hashed, err = copyAsset(asset, assetsDir)
+			if err != nil {
+				return match
+			}
+			assets[asset] = hashed
+		}
+
+		rel, e := filepath.Rel(cssDir, hashed)
+		if e != nil {
+			err = app.Error(e)
+			return match
+		}
+
+		return "url(" + m[1] + filepath.ToSlash(rel) + suffix + m[3] + ")"
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return result, assets, nil
+}
+
+// Copies the asset to the folder adding the hash of its content
+// to the name, and returns the new filename.
+func copyAsset(asset, dir string) (string, error) {
+	content, err := ioutil.ReadFile(asset)
+	if err != nil {
+		return "", app.Error(err)
+	}
+
+	ext := filepath.Ext(asset)
+	base := strings.TrimSuffix(filepath.Base(asset), ext)
+	hashed := filepath.Join(dir, base+"."+digest.Bytes(content)+ext)
+
+	if err := output.WriteFile(hashed, content); err != nil {
+		return "", err
+	}
+
+	return hashed, nil
+}
@@ -0,0 +1,37 @@
+package css
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "launchpad.net/gocheck"
+)
+
+type AssetsSuite struct{}
+
+var _ = Suite(&AssetsSuite{})
+
+func (s *AssetsSuite) TestFingerprint(c *C) {
+	dir := c.MkDir()
+	cssDir := filepath.Join(dir, "css")
+	assetsDir := filepath.Join(dir, "assets")
+
+	c.Assert(os.MkdirAll(filepath.Join(dir, "img"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "img", "a.png"), []byte("a"), 0644), IsNil)
+
+	src := ".a{background:url(../img/a.png)}.b{background:url('../img/a.png?#x')}"
+	result, assets, err := Fingerprint(src, cssDir, assetsDir)
+	c.Assert(err, IsNil)
+
+	// sha1 of "a"
+	hashed := "a.86f7e437faa5a7fce15d1ddcb9eaeaea377667b8.png"
+	c.Check(result, Equals, ".a{background:url(../assets/"+hashed+")}"+
+		".b{background:url('../assets/"+hashed+"?#x')}")
+	c.Check(assets, DeepEquals, map[string]string{
+		filepath.Join(dir, "img", "a.png"): filepath.Join(assetsDir, hashed),
+	})
+
+	_, err = os.Stat(filepath.Join(assetsDir, hashed))
+	c.Check(err, IsNil)
+}
@@ -0,0 +1,74 @@
+package css
+
+import (
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+var urlRe = regexp.MustCompile(`(?i)url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+
+// Concatenates and minifies the plain CSS files, writing them to w.
+// The relative url() references are rewritten to work from the
+// outputDir folder.
+func Bundle(w io.Writer, files []string, outputDir string) error {
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return app.Error(err)
+		}
+
+		src, err := RewriteUrls(string(content), filepath.Dir(file), outputDir)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(w, Minify(src)); err != nil {
+			return app.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// Changes the relative url() references of a CSS file placed in the from
+// folder to make them relative to the to folder.
+func RewriteUrls(src, from, to string) (string, error) {
+	var err error
+	result := urlRe.ReplaceAllStringFunc(src, func(match string) string {
+		m := urlRe.FindStringSubmatch(match)
+		u := m[2]
+		if !IsRelativeUrl(u) {
+			return match
+		}
+
+		rel, e := filepath.Rel(to, filepath.Join(from, filepath.FromSlash(u)))
+		if e != nil {
+			err = app.Error(e)
+			return match
+		}
+
+		return "url(" + m[1] + filepath.ToSlash(rel) + m[3] + ")"
+	})
+
+	return result, err
+}
+
+// Returns true if the url points to a file relative to the CSS file.
+func IsRelativeUrl(u string) bool {
+	if u == "" || path.IsAbs(u) || strings.HasPrefix(u, "#") {
+		return false
+	}
+
+	// Scheme (data:, http:, ...) or protocol-relative URLs
+	if strings.HasPrefix(u, "//") || strings.Contains(strings.SplitN(u, "/", 2)[0], ":") {
+		return false
+	}
+
+	return true
+}
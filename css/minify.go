@@ -0,0 +1,113 @@
+package css
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Minifies plain CSS code. It removes the comments (except the /*! ones,
+// usually licenses), collapses the whitespace and drops the last semicolon
+// of each block. Strings and url() contents are preserved untouched.
+func Minify(src string) string {
+	out := bytes.NewBuffer(nil)
+	space := false
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			if strings.HasPrefix(src[i:], "/*!") {
+				writeSpace(out, space)
+				out.WriteString(src[i:end])
+				space = false
+			} else {
+				space = space || out.Len() > 0
+			}
+			i = end - 1
+			continue
+
+		case isSpace(c):
+			space = out.Len() > 0
+			continue
+
+		case c == '"' || c == '\'':
+			end := stringEnd(src, i)
+			writeSpace(out, space)
+			out.WriteString(src[i:end])
+			i = end - 1
+
+		case hasPrefixFold(src[i:], "url("):
+			end := strings.IndexByte(src[i:], ')')
+			if end == -1 {
+				end = len(src)
+			} else {
+				end += i + 1
+			}
+			writeSpace(out, space)
+			out.WriteString(src[i:end])
+			i = end - 1
+
+		case c == '}':
+			// Remove the unneeded last semicolon of the block
+			if b := out.Bytes(); len(b) > 0 && b[len(b)-1] == ';' {
+				out.Truncate(out.Len() - 1)
+			}
+			out.WriteByte(c)
+
+		default:
+			if space && !strings.ContainsRune("{};,>)", rune(c)) {
+				writeSpace(out, true)
+			}
+			out.WriteByte(c)
+		}
+
+		space = false
+	}
+
+	return out.String()
+}
+
+// Writes a space separator if needed. It's not needed
+// after some punctuation characters nor after the comments.
+func writeSpace(out *bytes.Buffer, space bool) {
+	if !space {
+		return
+	}
+
+	b := out.Bytes()
+	if len(b) == 0 || strings.ContainsRune("{};,>:(", rune(b[len(b)-1])) {
+		return
+	}
+	if bytes.HasSuffix(b, []byte("*/")) {
+		return
+	}
+	out.WriteByte(' ')
+}
+
+// Returns the position after the end of the string that starts at i.
+func stringEnd(src string, i int) int {
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		if src[j] == '\\' {
+			j++
+		} else if src[j] == quote {
+			return j + 1
+		}
+	}
+	return len(src)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
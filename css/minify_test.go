@@ -0,0 +1,48 @@
+package css
+
+import (
+	"testing"
+
+	. "launchpad.net/gocheck"
+)
+
+// Hook up gocheck into the gotest runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type MinifySuite struct{}
+
+var _ = Suite(&MinifySuite{})
+
+func (s *MinifySuite) TestMinify(c *C) {
+	tests := map[string]string{
+		"a {\n  color: red;\n  margin: 0 auto;\n}\n": "a{color:red;margin:0 auto}",
+		"/* comment */ .a, .b > .c { top: 0 }":       ".a,.b>.c{top:0}",
+		"/*! license */\n.a { top: 0; }":             "/*! license */.a{top:0}",
+		".a { content: \"  x ; y  \"; }":             ".a{content:\"  x ; y  \"}",
+		".a { background: url( 'a b.png' ) }":        ".a{background:url( 'a b.png' )}",
+		"a :hover { top: 0 }":                        "a :hover{top:0}",
+		"@media screen and (max-width: 10px) { }":    "@media screen and (max-width:10px){}",
+		".a { width: calc(1px + 2px) }":              ".a{width:calc(1px + 2px)}",
+	}
+
+	for src, expected := range tests {
+		c.Check(Minify(src), Equals, expected)
+	}
+}
+
+func (s *MinifySuite) TestRewriteUrls(c *C) {
+	tests := map[string]string{
+		"url(img/a.png)":               "url(../../vendor/widget/img/a.png)",
+		"url('../fonts/b.woff')":       "url('../../vendor/fonts/b.woff')",
+		"url(/abs.png)":                "url(/abs.png)",
+		"url(data:image/png;base64,x)": "url(data:image/png;base64,x)",
+		"url(http://cdn/c.png)":        "url(http://cdn/c.png)",
+		"url(#filter)":                 "url(#filter)",
+	}
+
+	for src, expected := range tests {
+		result, err := RewriteUrls(src, "vendor/widget", "static/css")
+		c.Assert(err, IsNil)
+		c.Check(result, Equals, expected)
+	}
+}
@@ -0,0 +1,132 @@
+package digest
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+)
+
+var placeholderRe = regexp.MustCompile(`\{(sha1|hash)(?::(\d+))?\}`)
+
+// Returns the hexadecimal hash of the file content, truncated to
+// length characters if it's not zero.
+func File(filename, algorithm string, length int) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", app.Error(err)
+	}
+	defer f.Close()
+
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return "", app.Error(err)
+	}
+
+	return truncate(hex.EncodeToString(h.Sum(nil)), length), nil
+}
+
+// Returns the hexadecimal hash of the content using the
+// algorithm and length of the config.
+func Bytes(content []byte) string {
+	algorithm, length := defaults()
+
+	h, err := newHash(algorithm)
+	if err != nil {
+		// The algorithm has been validated with the config
+		panic(err)
+	}
+	h.Write(content)
+
+	return truncate(hex.EncodeToString(h.Sum(nil)), length)
+}
+
+// Returns the Subresource Integrity value of the file (sha384-...).
+func Integrity(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", app.Error(err)
+	}
+
+	sum := sha512.Sum384(content)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// Replaces the {sha1}, {hash} and {hash:N} placeholders of the pattern
+// with the hash of the file. {hash} uses the algorithm and length
+// of the config, {sha1} is always the whole SHA-1 hash.
+func Expand(pattern, filename string) (string, error) {
+	var err error
+	result := placeholderRe.ReplaceAllStringFunc(pattern, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		m := placeholderRe.FindStringSubmatch(match)
+
+		algorithm, length := defaults()
+		if m[1] == "sha1" {
+			algorithm, length = "sha1", 0
+		}
+		if m[2] != "" {
+			length, _ = strconv.Atoi(m[2])
+		}
+
+		var h string
+		h, err = File(filename, algorithm, length)
+		return h
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// Returns true if the pattern has any hash placeholder.
+func HasPlaceholder(pattern string) bool {
+	return placeholderRe.MatchString(pattern)
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	}
+	return nil, app.Errorf("hash algorithm not supported: %s", algorithm)
+}
+
+func defaults() (string, int) {
+	conf := config.Current()
+	if conf == nil || conf.Hash == nil {
+		return "sha1", 0
+	}
+
+	algorithm := conf.Hash.Algorithm
+	if algorithm == "" {
+		algorithm = "sha1"
+	}
+	return algorithm, conf.Hash.Length
+}
+
+func truncate(h string, length int) string {
+	if length > 0 && length < len(h) {
+		return h[:length]
+	}
+	return h
+}
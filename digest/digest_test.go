@@ -0,0 +1,61 @@
+package digest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "launchpad.net/gocheck"
+)
+
+// Hook up gocheck into the gotest runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type DigestSuite struct{}
+
+var _ = Suite(&DigestSuite{})
+
+func (s *DigestSuite) TestExpand(c *C) {
+	filename := filepath.Join(c.MkDir(), "compiled.js")
+	c.Assert(ioutil.WriteFile(filename, []byte("a"), 0644), IsNil)
+
+	tests := map[string]string{
+		"compiled.js":          "compiled.js",
+		"compiled.{sha1}.js":   "compiled.86f7e437faa5a7fce15d1ddcb9eaeaea377667b8.js",
+		"compiled.{hash}.js":   "compiled.86f7e437faa5a7fce15d1ddcb9eaeaea377667b8.js",
+		"compiled.{hash:8}.js": "compiled.86f7e437.js",
+		"{sha1:4}/{hash:6}.js": "86f7/86f7e4.js",
+	}
+
+	for pattern, expected := range tests {
+		result, err := Expand(pattern, filename)
+		c.Assert(err, IsNil)
+		c.Check(result, Equals, expected)
+	}
+}
+
+func (s *DigestSuite) TestFile(c *C) {
+	filename := filepath.Join(c.MkDir(), "compiled.js")
+	c.Assert(ioutil.WriteFile(filename, []byte("a"), 0644), IsNil)
+
+	h, err := File(filename, "sha256", 0)
+	c.Assert(err, IsNil)
+	c.Check(h, Equals, "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb")
+
+	h, err = File(filename, "sha256", 10)
+	c.Assert(err, IsNil)
+	c.Check(h, Equals, "ca978112ca")
+
+	_, err = File(filename, "md4", 0)
+	c.Check(err, NotNil)
+}
+
+func (s *DigestSuite) TestIntegrity(c *C) {
+	filename := filepath.Join(c.MkDir(), "compiled.js")
+	c.Assert(ioutil.WriteFile(filename, []byte("alert('Hello, world.');"), 0644), IsNil)
+
+	// Example of the SRI spec
+	h, err := Integrity(filename)
+	c.Assert(err, IsNil)
+	c.Check(h, Equals, "sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO")
+}
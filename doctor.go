@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/toolchain"
+)
+
+// Oldest Java release that runs the Closure tools.
+const MIN_JAVA_VERSION = 6
+
+var javaVersionRe = regexp.MustCompile(`version "([^"]+)"`)
+
+type doctorCheck struct {
+	Name, Detail string
+	Err          error
+}
+
+type doctorReport struct {
+	checks []*doctorCheck
+}
+
+func (r *doctorReport) add(name, detail string, err error) {
+	r.checks = append(r.checks, &doctorCheck{Name: name, Detail: detail, Err: err})
+}
+
+// Adds a check that passes if the file or folder exists.
+func (r *doctorReport) path(name, filename string) {
+	var err error
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		err = fmt.Errorf("not found")
+	}
+	r.add(name, filename, err)
+}
+
+func (r *doctorReport) failed() int {
+	n := 0
+	for _, c := range r.checks {
+		if c.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *doctorReport) print() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Status\tCheck\tDetail")
+	for _, c := range r.checks {
+		switch {
+		case c.Err == nil:
+			fmt.Fprintf(w, "ok\t%s\t%s\n", c.Name, c.Detail)
+		case c.Detail == "":
+			fmt.Fprintf(w, "FAIL\t%s\t%s\n", c.Name, errorMessage(c.Err))
+		default:
+			fmt.Fprintf(w, "FAIL\t%s\t%s: %s\n", c.Name, c.Detail, errorMessage(c.Err))
+		}
+	}
+	w.Flush()
+
+	if n := r.failed(); n > 0 {
+		fmt.Printf("\n%d of %d checks failed\n", n, len(r.checks))
+	} else {
+		fmt.Printf("\nAll %d checks passed\n", len(r.checks))
+	}
+}
+
+// Checks the toolchain and the paths of the config, printing
+// a table with the results. Returns false if something failed.
+func doctor() bool {
+	report := new(doctorReport)
+
+	// The paths are checked one by one even if the config isn't
+	// valid, the validation stops at the first error.
+	conf, err := config.Read()
+	if err != nil {
+		version, javaErr := checkJava("java")
+		report.add("java", version, javaErr)
+		report.add("config", config.ConfPath, err)
+	} else {
+		report.add("config", config.ConfPath, config.Load())
+		checkConfig(report, conf)
+	}
+
+	report.print()
+	return report.failed() == 0
+}
+
+// Returns the version of a java binary, looking for it
+// in the PATH if it's not a path.
+func checkJava(java string) (string, error) {
+	bin, err := exec.LookPath(java)
+	if err != nil {
+		return java, fmt.Errorf("not found")
+	}
+
+	// The version is printed to stderr
+	output, err := exec.Command(bin, "-version").CombinedOutput()
+	if err != nil {
+		return bin, fmt.Errorf("java -version failed: %s", err)
+	}
+
+	m := javaVersionRe.FindStringSubmatch(string(output))
+	if m == nil {
+		return bin, fmt.Errorf("cannot read the java version")
+	}
+
+	major, err := javaMajorVersion(m[1])
+	if err != nil {
+		return m[1], err
+	}
+	if major < MIN_JAVA_VERSION {
+		return m[1], fmt.Errorf("java %d or newer is required", MIN_JAVA_VERSION)
+	}
+
+	return m[1], nil
+}
+
+// Returns the major number of a java version: 6 for "1.6.0_45"
+// and 11 for "11.0.2".
+func javaMajorVersion(version string) (int, error) {
+	parts := strings.Split(version, ".")
+	if parts[0] == "1" && len(parts) > 1 {
+		parts = parts[1:]
+	}
+
+	major, err := strconv.Atoi(strings.SplitN(parts[0], "-", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse the java version")
+	}
+	return major, nil
+}
+
+func checkConfig(report *doctorReport, conf *config.Config) {
+	if tc := conf.Toolchain; tc != nil {
+		for _, t := range tc.Tools {
+			var err error
+			if !toolchain.Installed(tc, t) {
+				err = fmt.Errorf("not installed")
+			}
+			report.add("tool "+t.Name, tc.Dir(t), err)
+		}
+	}
+
+	// The java of the PATH is checked once for all the
+	// compilers without their own binary.
+	checkedPath := false
+	java := func(name string, n config.JavaNode) {
+		if n.Java == "" {
+			if checkedPath {
+				return
+			}
+			checkedPath = true
+			name, n.Java = "java", "java"
+		}
+
+		version, err := checkJava(n.Java)
+		report.add(name, version, err)
+	}
+
+	if conf.Js != nil {
+		java("compiler java", conf.Js.JavaNode)
+		report.path("js root", conf.Js.Root)
+		report.path("compiler jar", conf.Js.Jar())
+		for _, input := range conf.Js.Inputs {
+			report.path("js input", filepath.Join(conf.Js.Root, input.File))
+		}
+		for _, extern := range conf.Js.Externs {
+			report.path("js extern", extern.File)
+		}
+		for _, prepend := range conf.Js.Prepends {
+			report.path("js prepend", filepath.Join(conf.Js.Root, prepend.File))
+		}
+	}
+
+	if conf.Gss != nil {
+		java("stylesheets java", conf.Gss.JavaNode)
+		report.path("stylesheets jar", conf.Gss.Jar())
+		for _, input := range conf.Gss.AllInputs() {
+			report.path("gss input", input.File)
+		}
+		for _, input := range conf.Gss.Css {
+			report.path("css input", input.File)
+		}
+		for _, b := range conf.Gss.Bundles {
+			for _, input := range b.Css {
+				report.path("css input", input.File)
+			}
+		}
+	}
+
+	if conf.Soy != nil {
+		java("templates java", conf.Soy.JavaNode)
+		if conf.Soy.Root != "" {
+			report.path("soy root", conf.Soy.Root)
+		}
+		report.path("templates jar", conf.Soy.Jar())
+	}
+
+	if conf.Library != nil {
+		report.path("library root", conf.Library.Root)
+		report.path("library base.js", filepath.Join(conf.Library.Root, "closure", "goog", "base.js"))
+	}
+}
+
+// Returns the message of the error without the call stack.
+func errorMessage(err error) string {
+	if appErr, ok := err.(*app.AppError); ok && appErr.OriginalErr != nil {
+		return appErr.OriginalErr.Error()
+	}
+	return err.Error()
+}
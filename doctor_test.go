@@ -0,0 +1,28 @@
+package main
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+type DoctorSuite struct{}
+
+var _ = Suite(&DoctorSuite{})
+
+func (s *DoctorSuite) TestJavaMajorVersion(c *C) {
+	versions := map[string]int{
+		"1.6.0_45":  6,
+		"1.7.0_21":  7,
+		"11.0.2":    11,
+		"17":        17,
+		"9-ea":      9,
+		"21.0.1-ea": 21,
+	}
+	for v, major := range versions {
+		n, err := javaMajorVersion(v)
+		c.Assert(err, IsNil)
+		c.Check(n, Equals, major)
+	}
+
+	_, err := javaMajorVersion("unknown")
+	c.Check(err, NotNil)
+}
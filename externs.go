@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/externs"
+	"github.com/ernestokarim/closurer/output"
+)
+
+// Runs the externs subcommands, i.e. "externs generate lib.js externs.js".
+func externsCmd(args []string) error {
+	if len(args) < 2 || len(args) > 3 || args[0] != "generate" {
+		return app.Errorf("Usage: closurer externs generate <file.js|file.d.ts> [<output>]")
+	}
+
+	name := args[1]
+	filename := ""
+	if len(args) == 3 {
+		filename = args[2]
+	}
+
+	return generateExterns(name, filename)
+}
+
+// Writes a first-pass externs file for a third-party library, or prints
+// it without an output file.
+func generateExterns(name, filename string) error {
+	content, err := externs.Generate(name)
+	if err != nil {
+		return err
+	}
+
+	if filename == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	if err := output.WriteFile(filename, []byte(content)); err != nil {
+		return err
+	}
+	log.Printf("Externs written to %s\n", filename)
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package externs
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	dtsNamespaceRe = regexp.MustCompile(`^(?:namespace|module)\s+(` + ident + `(?:\.` + ident + `)*)$`)
+	dtsClassRe     = regexp.MustCompile(`^(?:abstract\s+)?class\s+(` + ident + `)`)
+	dtsInterfaceRe = regexp.MustCompile(`^interface\s+(` + ident + `)`)
+	dtsEnumRe      = regexp.MustCompile(`^(?:const\s+)?enum\s+(` + ident + `)`)
+	dtsFunctionRe  = regexp.MustCompile(`^function\s+(` + ident + `)\s*(?:<[^(]*>)?\s*\((.*)\)`)
+	dtsVarRe       = regexp.MustCompile(`^(?:var|let|const)\s+(` + ident + `)`)
+	dtsMethodRe    = regexp.MustCompile(`^(` + ident + `)\??\s*(?:<[^(]*>)?\s*\((.*)\)`)
+	dtsPropertyRe  = regexp.MustCompile(`^(` + ident + `)\??\s*:`)
+	dtsEnumValueRe = regexp.MustCompile(`^(` + ident + `)\s*(=.*)?,?$`)
+	dtsModifiersRe = regexp.MustCompile(`^((?:export|declare|default|public|readonly|abstract)\s+)*`)
+)
+
+const (
+	contextNamespace = iota
+	contextClass
+	contextEnum
+	contextIgnore
+)
+
+type dtsContext struct {
+	kind int
+	name string
+}
+
+// Scans the declarations of a TypeScript declarations file: the global
+// variables, functions, classes, interfaces, enums and namespaces.
+func fromDts(src string) *symbols {
+	syms := newSymbols()
+	stack := []*dtsContext{{kind: contextNamespace}}
+
+	for _, stmt := range statements(stripComments(src)) {
+		cur := stack[len(stack)-1]
+		opens := strings.HasSuffix(stmt, "{")
+		if stmt == "}" {
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		stmt = strings.TrimSpace(strings.TrimSuffix(stmt, "{"))
+		stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+		stmt = dtsModifiersRe.ReplaceAllString(stmt, "")
+
+		next := &dtsContext{kind: contextIgnore}
+		switch cur.kind {
+		case contextNamespace:
+			next = dtsNamespaceMember(syms, cur.name, stmt)
+
+		case contextClass:
+			static := strings.HasPrefix(stmt, "static ")
+			stmt = strings.TrimPrefix(stmt, "static ")
+			prefix := cur.name + ".prototype."
+			if static {
+				prefix = cur.name + "."
+			}
+
+			if strings.HasPrefix(stmt, "constructor") {
+				if m := dtsMethodRe.FindStringSubmatch(stmt); m != nil {
+					syms.add(cur.name, kindClass, paramNames(m[2]))
+				}
+			} else if m := dtsMethodRe.FindStringSubmatch(stmt); m != nil {
+				syms.add(prefix+m[1], kindFunction, paramNames(m[2]))
+			} else if m := dtsPropertyRe.FindStringSubmatch(stmt); m != nil {
+				syms.add(prefix+m[1], kindValue, nil)
+			}
+
+		case contextEnum:
+			for _, v := range splitTopLevel(stmt) {
+				if m := dtsEnumValueRe.FindStringSubmatch(strings.TrimSpace(v)); m != nil {
+					syms.add(cur.name+"."+m[1], kindValue, nil)
+				}
+			}
+		}
+
+		if opens {
+			stack = append(stack, next)
+		}
+	}
+
+	return syms
+}
+
+// Adds a declaration of a namespace and returns the context
+// of its body if it has one.
+func dtsNamespaceMember(syms *symbols, ns, stmt string) *dtsContext {
+	name := func(n string) string {
+		if ns == "" {
+			return n
+		}
+		return ns + "." + n
+	}
+
+	if m := dtsNamespaceRe.FindStringSubmatch(stmt); m != nil {
+		syms.add(name(m[1]), kindObject, nil)
+		return &dtsContext{kind: contextNamespace, name: name(m[1])}
+	}
+	if m := dtsClassRe.FindStringSubmatch(stmt); m != nil {
+		syms.add(name(m[1]), kindClass, nil)
+		return &dtsContext{kind: contextClass, name: name(m[1])}
+	}
+	if m := dtsInterfaceRe.FindStringSubmatch(stmt); m != nil {
+		syms.add(name(m[1]), kindInterface, nil)
+		return &dtsContext{kind: contextClass, name: name(m[1])}
+	}
+	if m := dtsEnumRe.FindStringSubmatch(stmt); m != nil {
+		syms.add(name(m[1]), kindObject, nil)
+		return &dtsContext{kind: contextEnum, name: name(m[1])}
+	}
+	if m := dtsFunctionRe.FindStringSubmatch(stmt); m != nil {
+		syms.add(name(m[1]), kindFunction, paramNames(m[2]))
+	} else if m := dtsVarRe.FindStringSubmatch(stmt); m != nil {
+		syms.add(name(m[1]), kindValue, nil)
+	}
+
+	return &dtsContext{kind: contextIgnore}
+}
+
+// Splits the code in statements, ending each one at the braces,
+// the semicolons and the newlines outside of parens.
+func statements(src string) []string {
+	stmts := []string{}
+	cur := []byte{}
+	parens := 0
+
+	flush := func() {
+		if s := strings.TrimSpace(string(cur)); s != "" {
+			stmts = append(stmts, s)
+		}
+		cur = cur[:0]
+	}
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '(':
+			parens++
+			cur = append(cur, c)
+		case c == ')':
+			parens--
+			cur = append(cur, c)
+		case c == '{' && parens == 0:
+			cur = append(cur, c)
+			flush()
+		case c == '}' && parens == 0:
+			flush()
+			stmts = append(stmts, "}")
+		case (c == ';' || c == '\n') && parens == 0:
+			if c == ';' {
+				cur = append(cur, c)
+			}
+			flush()
+		default:
+			cur = append(cur, c)
+		}
+	}
+	flush()
+
+	return stmts
+}
@@ -0,0 +1,237 @@
+package externs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+const (
+	kindValue = iota
+	kindObject
+	kindFunction
+	kindClass
+	kindInterface
+)
+
+// Symbol declared in the externs.
+type symbol struct {
+	name   string
+	kind   int
+	params []string
+}
+
+// Ordered list of symbols, parents before their children.
+type symbols struct {
+	lst   []*symbol
+	index map[string]*symbol
+}
+
+func newSymbols() *symbols {
+	return &symbols{index: map[string]*symbol{}}
+}
+
+// Generates a first-pass externs file from a plain JS file or
+// a TypeScript declarations file (.d.ts).
+func Generate(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", app.Error(err)
+	}
+
+	var syms *symbols
+	if strings.HasSuffix(filename, ".d.ts") {
+		syms = fromDts(string(content))
+	} else {
+		syms = fromJs(string(content))
+	}
+
+	header := fmt.Sprintf("/**\n * @fileoverview Externs generated from %s.\n"+
+		" * Review them before using them.\n * @externs\n */\n", filepath.Base(filename))
+	return header + syms.String(), nil
+}
+
+// Adds a symbol, declaring its parents as objects if they're unknown. If
+// the symbol already exists its kind is upgraded when it's more specific.
+func (s *symbols) add(name string, kind int, params []string) {
+	if i := strings.LastIndex(name, "."); i != -1 {
+		parent := name[:i]
+		if strings.HasSuffix(parent, ".prototype") {
+			// Properties of the instances make the owner a class
+			s.add(strings.TrimSuffix(parent, ".prototype"), kindClass, nil)
+		} else {
+			s.add(parent, kindObject, nil)
+		}
+	}
+
+	sym, ok := s.index[name]
+	if !ok {
+		sym = &symbol{name: name, kind: kind, params: params}
+		s.index[name] = sym
+		s.lst = append(s.lst, sym)
+		return
+	}
+
+	if kind > sym.kind {
+		sym.kind = kind
+	}
+	if params != nil {
+		sym.params = params
+	}
+}
+
+func (s *symbols) has(name string) bool {
+	_, ok := s.index[name]
+	return ok
+}
+
+func (s *symbols) String() string {
+	w := bytes.NewBuffer(nil)
+
+	for _, sym := range s.lst {
+		top := !strings.Contains(sym.name, ".")
+
+		fmt.Fprintf(w, "\n/**\n")
+		switch sym.kind {
+		case kindValue:
+			fmt.Fprintf(w, " * @type {?}\n")
+		case kindObject:
+			fmt.Fprintf(w, " * @const\n")
+		case kindClass:
+			fmt.Fprintf(w, " * @constructor\n")
+		case kindInterface:
+			fmt.Fprintf(w, " * @record\n")
+		}
+		for _, p := range sym.params {
+			fmt.Fprintf(w, " * @param {?=} %s\n", p)
+		}
+		if sym.kind == kindFunction {
+			fmt.Fprintf(w, " * @return {?}\n")
+		}
+		fmt.Fprintf(w, " */\n")
+
+		switch sym.kind {
+		case kindValue:
+			if top {
+				fmt.Fprintf(w, "var %s;\n", sym.name)
+			} else {
+				fmt.Fprintf(w, "%s;\n", sym.name)
+			}
+
+		case kindObject:
+			if top {
+				fmt.Fprintf(w, "var %s = {};\n", sym.name)
+			} else {
+				fmt.Fprintf(w, "%s = {};\n", sym.name)
+			}
+
+		default:
+			params := strings.Join(sym.params, ", ")
+			if top {
+				fmt.Fprintf(w, "function %s(%s) {}\n", sym.name, params)
+			} else {
+				fmt.Fprintf(w, "%s = function(%s) {};\n", sym.name, params)
+			}
+		}
+	}
+
+	return w.String()
+}
+
+// Returns the names of a list of parameters, removing the types,
+// default values and rest markers.
+func paramNames(params string) []string {
+	names := []string{}
+	for _, p := range splitTopLevel(params) {
+		p = strings.TrimSpace(p)
+		p = strings.TrimPrefix(p, "...")
+		if i := strings.IndexAny(p, "?:="); i != -1 {
+			p = p[:i]
+		}
+		p = strings.TrimSpace(p)
+		if isIdent(p) {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// Splits the list by commas outside of brackets.
+func splitTopLevel(s string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}', '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		letter := c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Removes the comments and the content of the strings, keeping the newlines.
+func stripComments(src string) string {
+	w := bytes.NewBuffer(nil)
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i < len(src) {
+				w.WriteByte('\n')
+			}
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				end = len(src) - i - 2
+			}
+			w.WriteString(strings.Repeat("\n", strings.Count(src[i:i+2+end], "\n")))
+			i += end + 3
+
+		case c == '"' || c == '\'' || c == '`':
+			w.WriteByte(c)
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' {
+					i++
+				} else if src[i] == '\n' {
+					w.WriteByte('\n')
+				}
+			}
+			w.WriteByte(c)
+
+		default:
+			w.WriteByte(c)
+		}
+	}
+	return w.String()
+}
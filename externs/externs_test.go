@@ -0,0 +1,144 @@
+package externs
+
+import (
+	"testing"
+
+	. "launchpad.net/gocheck"
+)
+
+// Hook up gocheck into the gotest runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type ExternsSuite struct{}
+
+var _ = Suite(&ExternsSuite{})
+
+func (s *ExternsSuite) TestJs(c *C) {
+	syms := fromJs(`
+(function() {
+  function Lib(name) {}
+  Lib.prototype.render = function(el, opts) {};
+  Lib.VERSION = '1.0';
+  window.Lib = Lib;
+})();
+var util = {
+  format: function(str) {},
+  debug: false
+};
+`)
+	c.Check(syms.String(), Equals, `
+/**
+ * @constructor
+ */
+function Lib() {}
+
+/**
+ * @const
+ */
+var util = {};
+
+/**
+ * @param {?=} el
+ * @param {?=} opts
+ * @return {?}
+ */
+Lib.prototype.render = function(el, opts) {};
+
+/**
+ * @type {?}
+ */
+Lib.VERSION;
+
+/**
+ * @param {?=} str
+ * @return {?}
+ */
+util.format = function(str) {};
+
+/**
+ * @type {?}
+ */
+util.debug;
+`)
+}
+
+func (s *ExternsSuite) TestDts(c *C) {
+	syms := fromDts(`
+declare namespace Lib {
+  // Comments are ignored
+  class Widget {
+    constructor(el: HTMLElement);
+    static create(): Widget;
+    render(opts?: {x: number}): void;
+    id: string;
+  }
+  interface Options {
+    debug?: boolean;
+  }
+  enum Mode { A, B = 2 }
+  function init(opts: Options): void;
+}
+declare module "lib" {
+  export = Lib;
+}
+`)
+	c.Check(syms.String(), Equals, `
+/**
+ * @const
+ */
+var Lib = {};
+
+/**
+ * @constructor
+ * @param {?=} el
+ */
+Lib.Widget = function(el) {};
+
+/**
+ * @return {?}
+ */
+Lib.Widget.create = function() {};
+
+/**
+ * @param {?=} opts
+ * @return {?}
+ */
+Lib.Widget.prototype.render = function(opts) {};
+
+/**
+ * @type {?}
+ */
+Lib.Widget.prototype.id;
+
+/**
+ * @record
+ */
+Lib.Options = function() {};
+
+/**
+ * @type {?}
+ */
+Lib.Options.prototype.debug;
+
+/**
+ * @const
+ */
+Lib.Mode = {};
+
+/**
+ * @type {?}
+ */
+Lib.Mode.A;
+
+/**
+ * @type {?}
+ */
+Lib.Mode.B;
+
+/**
+ * @param {?=} opts
+ * @return {?}
+ */
+Lib.init = function(opts) {};
+`)
+}
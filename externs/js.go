@@ -0,0 +1,101 @@
+package externs
+
+import (
+	"regexp"
+	"strings"
+)
+
+const ident = `[A-Za-z_$][\w$]*`
+
+var (
+	jsFunctionRe = regexp.MustCompile(`^\s*function\s+(` + ident + `)\s*\(([^)]*)\)`)
+	jsVarRe      = regexp.MustCompile(`^\s*(?:var|let|const)\s+(` + ident + `)\s*(=\s*(.*))?`)
+	jsWindowRe   = regexp.MustCompile(`^\s*(?:window|self|globalThis)\.(` + ident + `)\s*=\s*(.*)`)
+	jsAssignRe   = regexp.MustCompile(`^\s*(` + ident + `(?:\.` + ident + `)+)\s*=[^=]\s*(.*)`)
+	jsMemberRe   = regexp.MustCompile(`^\s*(` + ident + `)\s*:\s*(.*)`)
+	jsFuncExprRe = regexp.MustCompile(`^function\s*(?:` + ident + `)?\s*\(([^)]*)\)`)
+)
+
+// Scans the global symbols of a plain JS file: the top level functions and
+// variables, the ones assigned to window, their properties and the
+// members of the top level object literals.
+func fromJs(src string) *symbols {
+	syms := newSymbols()
+	lines := strings.Split(stripComments(src), "\n")
+
+	// First collect the globals, then their properties
+	depth := 0
+	for _, line := range lines {
+		if depth == 0 {
+			if m := jsFunctionRe.FindStringSubmatch(line); m != nil {
+				syms.add(m[1], functionKind(m[1]), paramNames(m[2]))
+			} else if m := jsVarRe.FindStringSubmatch(line); m != nil {
+				addJsValue(syms, m[1], m[3])
+			}
+		}
+		if m := jsWindowRe.FindStringSubmatch(line); m != nil {
+			addJsValue(syms, m[1], m[2])
+		}
+		depth += braces(line)
+	}
+
+	depth = 0
+	literal := ""
+	for _, line := range lines {
+		if m := jsAssignRe.FindStringSubmatch(line); m != nil {
+			root := m[1][:strings.Index(m[1], ".")]
+			if syms.has(root) && root != "window" {
+				addJsValue(syms, m[1], m[2])
+			}
+		}
+
+		// Members of the object literals assigned to globals
+		if literal != "" && depth == 1 {
+			if m := jsMemberRe.FindStringSubmatch(line); m != nil {
+				addJsValue(syms, literal+"."+m[1], m[2])
+			}
+		}
+		if depth == 0 {
+			literal = ""
+			if m := jsVarRe.FindStringSubmatch(line); m != nil &&
+				strings.HasSuffix(strings.TrimSpace(m[3]), "{") {
+				literal = m[1]
+			}
+		}
+
+		depth += braces(line)
+	}
+
+	return syms
+}
+
+// Adds a value, checking if it's a function or an object.
+func addJsValue(syms *symbols, name, value string) {
+	value = strings.TrimSpace(value)
+
+	if m := jsFuncExprRe.FindStringSubmatch(value); m != nil {
+		syms.add(name, functionKind(name), paramNames(m[1]))
+		return
+	}
+
+	if strings.HasPrefix(value, "{") || strings.Contains(value, "|| {}") {
+		syms.add(name, kindObject, nil)
+		return
+	}
+
+	syms.add(name, kindValue, nil)
+}
+
+// Capitalized functions are usually constructors.
+func functionKind(name string) int {
+	last := name[strings.LastIndex(name, ".")+1:]
+	if !strings.Contains(name, ".prototype.") && last[0] >= 'A' && last[0] <= 'Z' {
+		return kindClass
+	}
+	return kindFunction
+}
+
+// Returns the change in the depth of braces of the line.
+func braces(line string) int {
+	return strings.Count(line, "{") - strings.Count(line, "}")
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
@@ -19,8 +18,8 @@ func Compile() error {
 	conf := config.Current()
 	target := conf.Gss.CurTarget()
 
-	// Output early if there's no GSS files.
-	if conf.Gss == nil {
+	// Output early if there's no GSS files or target.
+	if target == nil {
 		if err := cleanRenamingMap(); err != nil {
 			return err
 		}
@@ -30,7 +29,7 @@ func Compile() error {
 
 	// Check if the cached version is still ok
 	modified := false
-	for _, input := range conf.Gss.Inputs {
+	for _, input := range conf.Gss.AllInputs() {
 		if m, err := cache.Modified("compile", input.File); err != nil {
 			return err
 		} else if m {
@@ -56,59 +55,169 @@ func Compile() error {
 		funcs = append(funcs, f.Name)
 	}
 
+	// Prepare the output options
+	options := []string{}
+	if target.Vendor != "" {
+		options = append(options, "--vendor", target.Vendor)
+	}
+	if target.PrettyPrint == "true" {
+		options = append(options, "--pretty-print")
+	}
+	for _, p := range target.AllowedProps {
+		options = append(options, "--allowed-unrecognized-property", p.Name)
+	}
+
 	// Prepare the renaming map args
 	renaming := []string{}
+	renamingMap := []string{}
 	if target.Rename == "true" {
-		renaming = []string{
-			"--output-renaming-map-format", "CLOSURE_COMPILED",
-			"--rename", "CLOSURE",
-			"--output-renaming-map", path.Join(conf.Build, config.RENAMING_MAP_NAME),
+		format := "CLOSURE_COMPILED"
+		if target.RenamingMapFormat != "" {
+			format = target.RenamingMapFormat
+		}
+
+		mapFile := path.Join(conf.Build, config.RENAMING_MAP_NAME)
+		if target.RenamingMap != "" {
+			mapFile = target.RenamingMap
+		}
+
+		renaming = []string{"--rename", "CLOSURE"}
+		for _, class := range target.ExcludedClasses {
+			renaming = append(renaming, "--excluded-classes-from-renaming", class.Name)
+		}
+		renamingMap = []string{
+			"--output-renaming-map-format", format,
+			"--output-renaming-map", mapFile,
+		}
+
+		// Bundles are compiled apart, so the map is generated first scanning
+		// all the inputs and then shared between all the compilations.
+		if len(conf.Gss.Bundles) > 0 {
+			args := append(renamingMap, renaming...)
+			args = append(args, funcs...)
+			args = append(args, options...)
+			args = append(args, defineArgs(target)...)
+			args = append(args, inputArgs(conf.Gss.AllInputs())...)
+			if err := run(filepath.Join(conf.Build, config.RENAMING_PASS_NAME), args); err != nil {
+				return err
+			}
+
+			renaming = append(renaming,
+				"--input-renaming-map-format", format,
+				"--input-renaming-map", mapFile)
+			renamingMap = []string{}
+		}
+	}
+
+	args := []string{}
+	args = append(args, funcs...)
+	args = append(args, renaming...)
+	args = append(args, options...)
+	args = append(args, defineArgs(target)...)
+
+	// Compile the main stylesheet, the only one that outputs the renaming map
+	// if there are no bundles.
+	err := compileStylesheet(target, config.CSS_NAME, config.CSS_RTL_NAME,
+		renamingMap, args, conf.Gss.Inputs)
+	if err != nil {
+		return err
+	}
+
+	for _, bundle := range conf.Gss.Bundles {
+		err := compileStylesheet(target, config.BundleCssName(bundle.Name, false),
+			config.BundleCssName(bundle.Name, true), nil, args, bundle.Inputs)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Prepare the defines
+	log.Println("Done compiling GSS!")
+
+	return nil
+}
+
+// Compiles a stylesheet, and its flipped version if the target asks for it.
+// The renaming map args are only passed to the main compilation.
+func compileStylesheet(target *config.GssTargetNode, name, rtlName string,
+	renamingMap, args []string, inputs []*config.InputNode) error {
+	conf := config.Current()
+
+	// Leave empty files if there's only plain CSS, it will be
+	// appended later anyway
+	if len(inputs) == 0 {
+		if err := cleanFile(filepath.Join(conf.Build, name)); err != nil {
+			return err
+		}
+		return cleanFile(filepath.Join(conf.Build, rtlName))
+	}
+
+	args = append(append([]string{}, args...), inputArgs(inputs)...)
+
+	mainArgs := append([]string{}, renamingMap...)
+	mainArgs = append(mainArgs, args...)
+	if target.Orientation != "" {
+		mainArgs = append(mainArgs, "--output-orientation", target.Orientation)
+	}
+	if err := run(filepath.Join(conf.Build, name), mainArgs); err != nil {
+		return err
+	}
+
+	// Compile the flipped stylesheet. The renaming is deterministic, so
+	// the classes will match the ones in the map of the main stylesheet.
+	if target.Rtl == "true" {
+		rtlArgs := append([]string{}, args...)
+		rtlArgs = append(rtlArgs, "--output-orientation", "RTL")
+		if err := run(filepath.Join(conf.Build, rtlName), rtlArgs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func defineArgs(target *config.GssTargetNode) []string {
 	defines := []string{}
 	for _, define := range target.Defines {
 		defines = append(defines, "--define", define.Name)
 	}
+	return defines
+}
 
-	// Prepare the inputs
-	inputs := []string{}
-	for _, input := range conf.Gss.Inputs {
-		inputs = append(inputs, input.File)
+func inputArgs(inputs []*config.InputNode) []string {
+	files := []string{}
+	for _, input := range inputs {
+		files = append(files, input.File)
 	}
+	return files
+}
+
+// Runs the GSS compiler writing the result to the output file.
+func run(output string, args []string) error {
+	conf := config.Current()
 
 	// Prepare the command
-	cmd := exec.Command(
-		"java",
-		"-jar", path.Join(conf.Gss.Compiler, "build", "closure-stylesheets.jar"),
-		"--output-file", filepath.Join(conf.Build, config.CSS_NAME))
-	cmd.Args = append(cmd.Args, funcs...)
-	cmd.Args = append(cmd.Args, renaming...)
-	cmd.Args = append(cmd.Args, inputs...)
-	cmd.Args = append(cmd.Args, defines...)
+	cmd := conf.Gss.Command(conf.Gss.Jar(), "--output-file", output)
+	cmd.Args = append(cmd.Args, args...)
 
 	// Output the command if asked to
 	if config.OutputCmd {
-		fmt.Println("java", strings.Join(cmd.Args, " "))
+		fmt.Println(strings.Join(cmd.Args, " "))
 	}
 
 	// Run the compiler
-	output, err := cmd.CombinedOutput()
+	out, err := cmd.CombinedOutput()
 	if err != nil {
-		if len(output) != 0 {
-			fmt.Println(string(output))
+		if len(out) != 0 {
+			fmt.Println(string(out))
 		}
 
 		return app.Errorf("exec error: %s", err)
 	}
 
-	if len(output) > 0 {
-		log.Println("Output from GSS compiler:\n", string(output))
+	if len(out) > 0 {
+		log.Println("Output from GSS compiler:\n", string(out))
 	}
 
-	log.Println("Done compiling GSS!")
-
 	return nil
 }
 
@@ -117,7 +226,12 @@ func cleanRenamingMap() error {
 
 	// Create/Clean the renaming map file to avoid compilation errors (the JS
 	// compiler assumes there's a file with this name there).
-	f, err := os.Create(path.Join(conf.Build, config.RENAMING_MAP_NAME))
+	return cleanFile(path.Join(conf.Build, config.RENAMING_MAP_NAME))
+}
+
+// Creates an empty file, truncating it if it already exists.
+func cleanFile(name string) error {
+	f, err := os.Create(name)
 	if err != nil {
 		return app.Error(err)
 	}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/css"
+	"github.com/ernestokarim/closurer/output"
+)
+
+// Data available in the templates of the HTML pages.
+type PageData struct {
+	Target string
+
+	// URLs of the main JS & CSS outputs, and of the legacy JS
+	// bundle if the target has differential loading
+	Js, LegacyJs, Css string
+
+	// URLs and integrity values of the outputs of the page, indexed by their
+	// mapping key without the target prefix (js, css, css-rtl, css-<bundle>,
+	// ...). The server only has the modern JS bundle.
+	Urls      map[string]string
+	Integrity map[string]string
+
+	CriticalCss template.CSS
+
+	// True if the page is rendered by the server instead of a build
+	Serve bool
+}
+
+// Renders the HTML pages of the current target.
+func outputHtml() error {
+	conf := config.Current()
+	prefix := config.SelectedTarget + "-"
+
+	// Added at the end, the pages don't link each other
+	pages := map[string]string{}
+
+	for _, h := range conf.Htmls {
+		filename := h.TargetOutput(config.SelectedTarget)
+		data := &PageData{
+			Target:    config.SelectedTarget,
+			Urls:      map[string]string{},
+			Integrity: map[string]string{},
+		}
+
+		for _, o := range pageOutputs() {
+			f, ok := outputs[prefix+o.Key]
+			if !ok {
+				continue
+			}
+
+			u, err := pageUrl(h, filename, f)
+			if err != nil {
+				return err
+			}
+			data.Urls[o.Key] = u
+
+			if integrity, ok := integrities[prefix+o.Key]; ok {
+				data.Integrity[o.Key] = integrity
+			}
+		}
+
+		data.Js = data.Urls["js"]
+		data.LegacyJs = data.Urls["js-legacy"]
+		data.Css = data.Urls["css"]
+
+		buf := bytes.NewBuffer(nil)
+		if err := renderPage(buf, h, data); err != nil {
+			return err
+		}
+		if err := output.Write(filename, buf); err != nil {
+			return err
+		}
+
+		pages[prefix+"html-"+h.PageName()] = filename
+	}
+
+	for key, filename := range pages {
+		addOutput(key, filename, filename)
+	}
+
+	return nil
+}
+
+// Renders a HTML page that loads the code from the server.
+func Page(r *app.Request) error {
+	if err := config.Load(); err != nil {
+		return err
+	}
+
+	conf := config.Current()
+	name := mux.Vars(r.Req)["name"]
+
+	for _, h := range conf.Htmls {
+		if h.PageName() != name {
+			continue
+		}
+
+		data := &PageData{
+			Target:    config.SelectedTarget,
+			Urls:      map[string]string{},
+			Integrity: map[string]string{},
+			Serve:     true,
+		}
+		for _, o := range pageOutputs() {
+			if o.ServeUrl != "" {
+				data.Urls[o.Key] = o.ServeUrl
+			}
+		}
+		data.Js = data.Urls["js"]
+		data.Css = data.Urls["css"]
+
+		return renderPage(r.W, h, data)
+	}
+
+	return app.NotFound()
+}
+
+// Output of the current target available in the pages.
+type pageOutput struct {
+	// Mapping key without the target prefix
+	Key string
+
+	// URL of the output in the server, if it's served
+	ServeUrl string
+}
+
+// Returns the outputs of the current target the pages can use: the JS
+// bundles, the stylesheets and the bundles, and their RTL versions.
+func pageOutputs() []*pageOutput {
+	conf := config.Current()
+	result := []*pageOutput{}
+
+	tjs := conf.Js.CurTarget()
+	if tjs != nil {
+		result = append(result, &pageOutput{Key: "js", ServeUrl: "/compile"})
+		if tjs.LegacyOutput != "" {
+			result = append(result, &pageOutput{Key: "js-legacy"})
+		}
+	}
+
+	tgss := conf.Gss.CurTarget()
+	if tgss == nil {
+		return result
+	}
+
+	// RAW targets inject the styles from the JS code
+	serve := func(u string) string {
+		if tjs != nil && tjs.Mode == "RAW" {
+			return ""
+		}
+		return u
+	}
+
+	rtl := tgss.Rtl == "true"
+	if len(conf.Gss.Inputs) > 0 || len(conf.Gss.Css) > 0 {
+		result = append(result, &pageOutput{Key: "css", ServeUrl: serve("/css")})
+		if rtl {
+			result = append(result, &pageOutput{Key: "css-rtl", ServeUrl: serve("/css?rtl=true")})
+		}
+	}
+	for _, b := range conf.Gss.Bundles {
+		u := "/css?bundle=" + url.QueryEscape(b.Name)
+		result = append(result, &pageOutput{Key: "css-" + b.Name, ServeUrl: serve(u)})
+		if rtl {
+			result = append(result, &pageOutput{Key: "css-rtl-" + b.Name, ServeUrl: serve(u + "&rtl=true")})
+		}
+	}
+
+	return result
+}
+
+func renderPage(w io.Writer, h *config.HtmlNode, data *PageData) error {
+	if h.Critical != "" {
+		content, err := ioutil.ReadFile(h.Critical)
+		if err != nil {
+			return app.Error(err)
+		}
+		data.CriticalCss = template.CSS(css.Minify(string(content)))
+	}
+
+	t, err := template.ParseFiles(h.Input)
+	if err != nil {
+		return app.Error(err)
+	}
+
+	if err := t.Execute(w, data); err != nil {
+		return app.Error(err)
+	}
+
+	return nil
+}
+
+// Returns the URL of an output file to be used from the page.
+func pageUrl(h *config.HtmlNode, page, filename string) (string, error) {
+	if h.Root == "" {
+		rel, err := filepath.Rel(filepath.Dir(page), filename)
+		if err != nil {
+			return "", app.Error(err)
+		}
+		return filepath.ToSlash(rel), nil
+	}
+
+	rel, err := filepath.Rel(h.Root, filename)
+	if err != nil {
+		return "", app.Error(err)
+	}
+
+	prefix := h.Prefix
+	if prefix == "" {
+		prefix = "/"
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + filepath.ToSlash(rel), nil
+}
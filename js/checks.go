@@ -0,0 +1,86 @@
+package js
+
+import (
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/output"
+)
+
+var (
+	checksStartRe = regexp.MustCompile(`(?:Options:|following:)`)
+	checkNameRe   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
+)
+
+// Updates the list of diagnostic groups accepted in the checks
+// with the ones the compiler prints in its --help output.
+func UpdateChecks() error {
+	conf := config.Current()
+	if conf.Js == nil {
+		return app.Errorf("No JS compiler configured")
+	}
+
+	// The help is printed with an error exit code
+	out, _ := conf.Js.Command(conf.Js.Jar(), "--help").CombinedOutput()
+
+	checks := ParseChecks(string(out))
+	if len(checks) == 0 {
+		return app.Errorf("cannot find the checks in the compiler help:\n%s", out)
+	}
+
+	filename := filepath.Join(conf.Build, config.CHECKS_NAME)
+	content := strings.Join(checks, "\n") + "\n"
+	if err := output.WriteFile(filename, []byte(content)); err != nil {
+		return err
+	}
+
+	log.Printf("%d checks written to %s\n", len(checks), filename)
+
+	return nil
+}
+
+// Extracts the diagnostic groups listed in the help
+// of the --jscomp_error flag.
+func ParseChecks(help string) []string {
+	lines := strings.Split(help, "\n")
+
+	// Join the description of the flag, that continues
+	// until the next one starts
+	desc := ""
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--jscomp_error") {
+			continue
+		}
+		for _, l := range lines[i:] {
+			if desc != "" && strings.HasPrefix(strings.TrimSpace(l), "--") {
+				break
+			}
+			if j := strings.Index(l, ":"); j != -1 && desc == "" {
+				l = l[j+1:]
+			}
+			desc += " " + strings.TrimSpace(l)
+		}
+		break
+	}
+
+	loc := checksStartRe.FindStringIndex(desc)
+	if loc == nil {
+		return nil
+	}
+
+	checks := []string{}
+	for _, name := range strings.Split(desc[loc[1]:], ",") {
+		name = strings.TrimSpace(name)
+		if checkNameRe.MatchString(name) {
+			checks = append(checks, name)
+		}
+	}
+	sort.Strings(checks)
+
+	return checks
+}
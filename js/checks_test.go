@@ -0,0 +1,30 @@
+package js
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+type ChecksSuite struct{}
+
+var _ = Suite(&ChecksSuite{})
+
+func (s *ChecksSuite) TestParseChecks(c *C) {
+	help := ` --js_output_file VAL                   : Primary output filename. If not
+                                          specified, output is written to stdout
+ --jscomp_error VAL                     : Make the named class of warnings an
+                                          error. Options:accessControls,
+                                          ambiguousFunctionDecl, checkRegExp,
+                                          checkTypes, visibility
+ --jscomp_off VAL                       : Turn off the named class of warnings.
+                                          Options:accessControls
+`
+	c.Check(ParseChecks(help), DeepEquals, []string{
+		"accessControls",
+		"ambiguousFunctionDecl",
+		"checkRegExp",
+		"checkTypes",
+		"visibility",
+	})
+
+	c.Check(ParseChecks("Usage: --help"), HasLen, 0)
+}
@@ -3,13 +3,13 @@ package js
 import (
 	"fmt"
 	"log"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/ernestokarim/closurer/app"
 	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/domain"
 	"github.com/ernestokarim/closurer/gss"
 	"github.com/ernestokarim/closurer/hooks"
 	"github.com/ernestokarim/closurer/soy"
@@ -41,9 +41,10 @@ func FullCompile() error {
 
 func Compile() error {
 	conf := config.Current()
-	target := conf.Js.CurTarget()
 
-	if conf.Js == nil {
+	// GSS-only target
+	target := conf.Js.CurTarget()
+	if target == nil {
 		return nil
 	}
 
@@ -56,10 +57,7 @@ func Compile() error {
 		return err
 	}
 
-	args := []string{
-		"-jar", path.Join(conf.Js.Compiler, "build", "compiler.jar"),
-		"--js_output_file", path.Join(conf.Build, config.JS_NAME),
-	}
+	args := []string{}
 
 	if conf.Library != nil {
 		args = append(args,
@@ -73,7 +71,11 @@ func Compile() error {
 		"--js", filepath.Join(conf.Build, config.RENAMING_MAP_NAME),
 	)
 
-	if conf.Js.SideEffects == "" {
+	if len(target.Exports) > 0 {
+		args = append(args, "--output_wrapper", umdWrapper(target.Library))
+	} else if target.Wrapper != "" {
+		args = append(args, "--output_wrapper", target.Wrapper)
+	} else if conf.Js.SideEffects != "true" {
 		args = append(args, "--output_wrapper", `(function(){%output%})();`)
 	}
 
@@ -83,13 +85,25 @@ func Compile() error {
 		}
 	}
 
+	// The exports of a library go after all the code
+	if len(target.Exports) > 0 {
+		if err := writeLibraryFiles(target); err != nil {
+			return err
+		}
+
+		args = append(args,
+			"--js", filepath.Join(conf.Build, config.EXPORTS_NAME),
+			"--externs", filepath.Join(conf.Build, config.LIBRARY_EXTERNS_NAME))
+	}
+
 	if target.Defines != nil {
 		for _, define := range target.Defines {
 			// If it's not a boolean, quote it
-			if define.Value != "true" && define.Value != "false" {
-				define.Value = "\"" + define.Value + "\""
+			value := define.Value
+			if value != "true" && value != "false" {
+				value = "\"" + value + "\""
 			}
-			args = append(args, "--define", define.Name+"="+define.Value)
+			args = append(args, "--define", define.Name+"="+value)
 		}
 	}
 
@@ -130,14 +144,66 @@ func Compile() error {
 		args = append(args, "--debug", "true")
 	}
 
+	if target.RewritePolyfills != "" {
+		args = append(args, "--rewrite_polyfills", target.RewritePolyfills)
+	}
+	if target.IsolatePolyfills != "" {
+		args = append(args, "--isolate_polyfills", target.IsolatePolyfills)
+	}
+
+	// Custom flags go last to override the previous ones
+	for _, f := range conf.Js.Flags {
+		if !target.HasFlag(f.Name) {
+			args = append(args, f.Args()...)
+		}
+	}
+	for _, f := range target.Flags {
+		args = append(args, f.Args()...)
+	}
+
 	log.Println("Compiling JS:", target.Name)
 
+	if err := run(target, deps, config.JS_NAME, target.LanguageOut, args); err != nil {
+		return err
+	}
+
+	// Differential loading; the server only uses the modern bundle
+	if target.LegacyOutput != "" && config.Build {
+		language := target.LegacyLanguageOut
+		if language == "" {
+			language = "ECMASCRIPT5"
+		}
+
+		log.Println("Compiling legacy JS:", target.Name)
+
+		if err := run(target, deps, config.JS_LEGACY_NAME, language, args); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Done compiling JS!")
+
+	return nil
+}
+
+// Runs the compiler writing the code in the build folder
+// with the name and the output language.
+func run(target *config.JsTargetNode, deps []*domain.Source, name, language string, args []string) error {
+	conf := config.Current()
+	filename := filepath.Join(conf.Build, name)
+
+	// The custom flags at the end of args can still override them
+	first := []string{"--js_output_file", filename}
+	if language != "" {
+		first = append(first, "--language_out", language)
+	}
+
 	// Prepare the command
-	cmd := exec.Command("java", args...)
+	cmd := conf.Js.Command(conf.Js.Jar(), append(first, args...)...)
 
 	// Output it if asked to
 	if config.OutputCmd {
-		fmt.Println("java", strings.Join(cmd.Args, " "))
+		fmt.Println(strings.Join(cmd.Args, " "))
 	}
 
 	// Run the JS compiler
@@ -154,7 +220,5 @@ func Compile() error {
 		log.Println("Output from JS compiler:\n", string(output))
 	}
 
-	log.Println("Done compiling JS!")
-
-	return nil
+	return addHeader(target, deps, filename)
 }
@@ -0,0 +1,185 @@
+package js
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/digest"
+	"github.com/ernestokarim/closurer/domain"
+	"github.com/ernestokarim/closurer/output"
+)
+
+var commentRe = regexp.MustCompile(`(?s)/\*[*!].*?\*/`)
+
+// Variables available in the banners. The Date is the one of the commit
+// in the fingerprinted outputs, so their hash only changes with the code.
+type BannerData struct {
+	Version, Commit, Date, Target string
+}
+
+// Adds the license comments of the sources and the banner of the
+// target to the top of the compiled file.
+func addHeader(target *config.JsTargetNode, deps []*domain.Source, filename string) error {
+	conf := config.Current()
+
+	if target.Licenses != "true" && target.Banner == "" {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return app.Error(err)
+	}
+	code := string(content)
+
+	header := bytes.NewBuffer(nil)
+
+	if target.Licenses == "true" {
+		licenses, err := collectLicenses(deps)
+		if err != nil {
+			return err
+		}
+
+		// The compiler keeps them too, reformatted; leave
+		// only the ones in the header
+		normalized := map[string]bool{}
+		for _, license := range licenses {
+			normalized[normalizeComment(license)] = true
+			header.WriteString(license + "\n")
+		}
+		code = removeLicenses(code, normalized)
+	}
+
+	if target.Banner != "" {
+		t, err := template.New("banner").Parse(target.Banner)
+		if err != nil {
+			return app.Error(err)
+		}
+
+		date := time.Now().UTC().Format(time.RFC3339)
+		if digest.HasPlaceholder(target.Output) || digest.HasPlaceholder(target.LegacyOutput) {
+			date = gitCommitDate()
+		}
+
+		data := &BannerData{
+			Version: conf.Js.Version,
+			Commit:  gitCommit(),
+			Date:    date,
+			Target:  target.Name,
+		}
+		if err := t.Execute(header, data); err != nil {
+			return app.Error(err)
+		}
+		header.WriteString("\n")
+	}
+
+	return output.WriteFile(filename, append(header.Bytes(), code...))
+}
+
+// Returns the @license, @preserve and /*! comments of the sources,
+// without repetitions.
+func collectLicenses(deps []*domain.Source) ([]string, error) {
+	seen := map[string]bool{}
+	licenses := []string{}
+
+	for _, dep := range deps {
+		if strings.Contains(dep.Filename, "_test.js") {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(dep.Filename)
+		if err != nil {
+			return nil, app.Error(err)
+		}
+
+		for _, comment := range commentRe.FindAllString(string(content), -1) {
+			license := strings.HasPrefix(comment, "/*!") ||
+				strings.Contains(comment, "@license") || strings.Contains(comment, "@preserve")
+			if license && !seen[comment] {
+				seen[comment] = true
+				licenses = append(licenses, comment)
+			}
+		}
+	}
+
+	return licenses, nil
+}
+
+// Removes the licenses, normalized, from the comments at the top of the
+// compiled code, where the compiler leaves them. The rest of the code isn't
+// touched, it could have comment delimiters inside the literals.
+func removeLicenses(code string, normalized map[string]bool) string {
+	result := bytes.NewBuffer(nil)
+	rest := code
+	for {
+		trimmed := strings.TrimLeft(rest, " \t\r\n")
+		if !strings.HasPrefix(trimmed, "/*") {
+			break
+		}
+		end := strings.Index(trimmed, "*/")
+		if end == -1 {
+			break
+		}
+
+		comment := trimmed[:end+2]
+		if normalized[normalizeComment(comment)] {
+			rest = strings.TrimPrefix(trimmed[end+2:], "\n")
+			continue
+		}
+
+		result.WriteString(rest[:len(rest)-len(trimmed)] + comment)
+		rest = trimmed[end+2:]
+	}
+	result.WriteString(rest)
+
+	return result.String()
+}
+
+// Returns the text of a comment without the delimiters, the stars
+// at the start of the lines and the differences in the whitespace.
+func normalizeComment(comment string) string {
+	comment = strings.TrimSpace(comment)
+	comment = strings.TrimPrefix(comment, "/*")
+	comment = strings.TrimSuffix(comment, "*/")
+	comment = strings.TrimLeft(comment, "*!")
+
+	words := []string{}
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "*")
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// Returns the short hash of the current git commit, or an
+// empty string if it's not available.
+func gitCommit() string {
+	out, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Returns the date of the current git commit in UTC, or an
+// empty string if it's not available.
+func gitCommitDate() string {
+	out, err := exec.Command("git", "log", "-1", "--format=%ct").Output()
+	if err != nil {
+		return ""
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return ""
+	}
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
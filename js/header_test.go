@@ -0,0 +1,30 @@
+package js
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+type HeaderSuite struct{}
+
+var _ = Suite(&HeaderSuite{})
+
+func (s *HeaderSuite) TestNormalizeComment(c *C) {
+	source := "/**\n * @license\n * Copyright 2013 The Authors.\n *   MIT licensed.\n */"
+	reindented := "/*\n @license\n Copyright 2013 The Authors.\n MIT licensed.\n*/"
+
+	c.Check(normalizeComment(source), Equals, "@license Copyright 2013 The Authors. MIT licensed.")
+	c.Check(normalizeComment(reindented), Equals, normalizeComment(source))
+	c.Check(normalizeComment("/*! v1.0 */"), Equals, "v1.0")
+}
+
+func (s *HeaderSuite) TestRemoveLicenses(c *C) {
+	license := "/**\n * @license MIT\n */"
+	normalized := map[string]bool{normalizeComment(license): true}
+
+	code := "/*\n @license MIT\n*/\n/* keep */\nvar a=\"/*\";var b=\"*/\";\n/*\n @license MIT\n*/\n"
+	c.Check(removeLicenses(code, normalized), Equals,
+		"/* keep */\nvar a=\"/*\";var b=\"*/\";\n/*\n @license MIT\n*/\n")
+
+	code = "var a=\"/*\";\n/* @license MIT */var b=1;"
+	c.Check(removeLicenses(code, normalized), Equals, code)
+}
@@ -0,0 +1,89 @@
+package js
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/output"
+)
+
+// Name of the object where the symbols are exported inside the UMD wrapper.
+const exportsVar = "__closurer_exports__"
+
+// Returns the output wrapper that publishes the library as an AMD module,
+// a CommonJS module or a global variable.
+func umdWrapper(name string) string {
+	return "(function(root, factory) {" +
+		"if (typeof define === 'function' && define.amd) {" +
+		"define([], function() { var e = {}; factory.call(root, e); return e; });" +
+		"} else if (typeof module === 'object' && module.exports) {" +
+		"factory.call(root, module.exports);" +
+		"} else {" +
+		"root['" + name + "'] = {}; factory.call(root, root['" + name + "']);" +
+		"}" +
+		"})(this, function(" + exportsVar + ") {%output%});"
+}
+
+// Writes the file with the exports of the library, and the externs the
+// compiler needs to know the exports object.
+func writeLibraryFiles(target *config.JsTargetNode) error {
+	conf := config.Current()
+
+	exports := bytes.NewBuffer(nil)
+	for _, e := range target.Exports {
+		if e.IsProperty() {
+			i := strings.LastIndex(e.Symbol, ".")
+			fmt.Fprintf(exports, "goog.exportProperty(%s, '%s', %s);\n",
+				e.Symbol[:i], e.PublicName(), e.Symbol)
+		} else {
+			fmt.Fprintf(exports, "goog.exportSymbol('%s', %s, %s);\n",
+				e.PublicName(), e.Symbol, exportsVar)
+		}
+	}
+
+	err := output.WriteFile(filepath.Join(conf.Build, config.EXPORTS_NAME), exports.Bytes())
+	if err != nil {
+		return err
+	}
+
+	externs := "/** @type {!Object} */\nvar " + exportsVar + ";\n"
+	return output.WriteFile(filepath.Join(conf.Build, config.LIBRARY_EXTERNS_NAME),
+		[]byte(externs))
+}
+
+// Returns the externs the consumers of the library need to use it
+// from their own compiled code.
+func LibraryExterns(target *config.JsTargetNode) string {
+	w := bytes.NewBuffer(nil)
+
+	fmt.Fprintf(w, "/**\n * @fileoverview Externs of the %s library.\n", target.Library)
+	fmt.Fprintf(w, " * @externs\n */\n\n")
+	fmt.Fprintf(w, "/** @const */\nvar %s = {};\n", target.Library)
+
+	// Public names of the exported symbols
+	names := map[string]string{}
+	for _, e := range target.Exports {
+		if !e.IsProperty() {
+			names[e.Symbol] = target.Library + "." + e.PublicName()
+			fmt.Fprintf(w, "\n/** @type {?} */\n%s;\n", names[e.Symbol])
+		}
+	}
+
+	for _, e := range target.Exports {
+		if !e.IsProperty() {
+			continue
+		}
+
+		owner := e.Symbol[:strings.Index(e.Symbol, ".prototype.")]
+		name, ok := names[owner]
+		if !ok {
+			name = "Object"
+		}
+		fmt.Fprintf(w, "\n/** @type {?} */\n%s.prototype.%s;\n", name, e.PublicName())
+	}
+
+	return w.String()
+}
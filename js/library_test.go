@@ -0,0 +1,45 @@
+package js
+
+import (
+	"testing"
+
+	. "launchpad.net/gocheck"
+
+	"github.com/ernestokarim/closurer/config"
+)
+
+// Hook up gocheck into the gotest runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type LibrarySuite struct{}
+
+var _ = Suite(&LibrarySuite{})
+
+func (s *LibrarySuite) TestLibraryExterns(c *C) {
+	target := &config.JsTargetNode{
+		Library: "MyLib",
+		Exports: []*config.ExportNode{
+			{Symbol: "my.lib.Widget"},
+			{Symbol: "my.lib.Widget.prototype.render"},
+			{Symbol: "my.lib.create", As: "newWidget"},
+		},
+	}
+
+	c.Check(LibraryExterns(target), Equals, `/**
+ * @fileoverview Externs of the MyLib library.
+ * @externs
+ */
+
+/** @const */
+var MyLib = {};
+
+/** @type {?} */
+MyLib.Widget;
+
+/** @type {?} */
+MyLib.newWidget;
+
+/** @type {?} */
+MyLib.Widget.prototype.render;
+`)
+}
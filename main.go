@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/ernestokarim/closurer/app"
 	"github.com/ernestokarim/closurer/config"
@@ -19,6 +20,45 @@ var exitServer = make(chan bool)
 func main() {
 	flag.Parse()
 
+	if flag.Arg(0) == "externs" {
+		if err := externsCmd(flag.Args()[1:]); err != nil {
+			err.(*app.AppError).Log()
+		}
+		return
+	}
+
+	if flag.Arg(0) == "doctor" {
+		if !doctor() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "config" {
+		if err := configCmd(flag.Args()[1:]); err != nil {
+			err.(*app.AppError).Log()
+		}
+		return
+	}
+
+	if flag.Arg(0) == "checks" {
+		config.IgnoreChecks = true
+		if err := config.Load(); err != nil {
+			log.Fatal(err)
+		}
+		if err := checksCmd(flag.Args()[1:]); err != nil {
+			err.(*app.AppError).Log()
+		}
+		return
+	}
+
+	if flag.Arg(0) == "toolchain" {
+		if err := toolchainCmd(flag.Args()[1:]); err != nil {
+			err.(*app.AppError).Log()
+		}
+		return
+	}
+
 	if config.BuildTargets == "" {
 		fmt.Println("Target required")
 		flag.Usage()
@@ -29,7 +69,25 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if config.Build {
+	if config.PrintConfig {
+		for _, t := range config.TargetList() {
+			config.SelectTarget(t)
+
+			if err := printConfig(); err != nil {
+				err.(*app.AppError).Log()
+				break
+			}
+		}
+	} else if config.Clean {
+		for _, t := range config.TargetList() {
+			config.SelectTarget(t)
+
+			if err := clean(); err != nil {
+				err.(*app.AppError).Log()
+				break
+			}
+		}
+	} else if config.Build {
 		for _, t := range config.TargetList() {
 			config.SelectTarget(t)
 
@@ -53,8 +111,10 @@ func serve() {
 	http.Handle("/", r)
 
 	r.Handle("/", app.Handler(home))
-	r.Handle("/compile", app.Handler(compile))
-	r.Handle("/input/{name:.+}", app.Handler(Input))
+	r.Handle("/compile", app.Handler(app.Gzip(compile)))
+	r.Handle("/css", app.Handler(app.Gzip(CompiledCss)))
+	r.Handle("/input/{name:.+}", app.Handler(app.Gzip(Input)))
+	r.Handle("/html/{name:.+}", app.Handler(Page))
 	r.Handle("/test/all", app.Handler(test.TestAll))
 	r.Handle("/test/list", app.Handler(test.TestList))
 	r.Handle("/test/{name:.+}", app.Handler(test.Main))
@@ -0,0 +1,91 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/output"
+)
+
+// Fingerprinted files produced by the builds, indexed by target and
+// mapping key. The newest file comes first.
+type History map[string]map[string][]string
+
+// Loads the history from the file. A missing file is an empty history.
+func LoadHistory(filename string) (History, error) {
+	h := History{}
+
+	f, err := os.Open(filename)
+	if err != nil && os.IsNotExist(err) {
+		return h, nil
+	} else if err != nil {
+		return nil, app.Error(err)
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&h); err != nil {
+		return nil, app.Errorf("cannot read the build history: %s", err)
+	}
+
+	return h, nil
+}
+
+// Saves the history to the file.
+func (h History) Save(filename string) error {
+	content, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return app.Error(err)
+	}
+
+	return output.WriteFile(filename, content)
+}
+
+// Adds a new file to the key of the target, as the newest one.
+func (h History) Add(target, key, filename string) {
+	if h[target] == nil {
+		h[target] = map[string][]string{}
+	}
+
+	files := []string{filename}
+	for _, f := range h[target][key] {
+		if f != filename {
+			files = append(files, f)
+		}
+	}
+	h[target][key] = files
+}
+
+// Removes from each key of the target all the files except the newest
+// keep ones, and returns the list of removed files. The files shared with
+// the newest keep ones of other targets (like a common stylesheet) are
+// removed from the history of the target but not returned.
+func (h History) Trim(target string, keep int) []string {
+	old := []string{}
+	for key, files := range h[target] {
+		if len(files) > keep {
+			h[target][key] = files[:keep]
+			old = append(old, files[keep:]...)
+		}
+	}
+
+	kept := map[string]bool{}
+	for _, keys := range h {
+		for _, files := range keys {
+			if len(files) > keep {
+				files = files[:keep]
+			}
+			for _, f := range files {
+				kept[f] = true
+			}
+		}
+	}
+
+	removed := []string{}
+	for _, f := range old {
+		if !kept[f] {
+			removed = append(removed, f)
+		}
+	}
+	return removed
+}
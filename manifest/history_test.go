@@ -0,0 +1,44 @@
+package manifest
+
+import (
+	. "launchpad.net/gocheck"
+)
+
+type HistorySuite struct{}
+
+var _ = Suite(&HistorySuite{})
+
+func (s *HistorySuite) TestTrim(c *C) {
+	h := History{}
+	h.Add("production", "production-js", "compiled.1.js")
+	h.Add("production", "production-js", "compiled.2.js")
+	h.Add("production", "production-js", "compiled.3.js")
+	h.Add("production", "production-js", "compiled.2.js")
+	h.Add("production", "production-css", "compiled.1.css")
+	h.Add("dev", "dev-js", "dev.1.js")
+	h.Add("dev", "dev-js", "dev.2.js")
+
+	c.Check(h["production"]["production-js"], DeepEquals,
+		[]string{"compiled.2.js", "compiled.3.js", "compiled.1.js"})
+
+	c.Check(h.Trim("production", 2), DeepEquals, []string{"compiled.1.js"})
+	c.Check(h["production"]["production-js"], DeepEquals,
+		[]string{"compiled.2.js", "compiled.3.js"})
+	c.Check(h["production"]["production-css"], DeepEquals, []string{"compiled.1.css"})
+	c.Check(h["dev"]["dev-js"], HasLen, 2)
+}
+
+func (s *HistorySuite) TestTrimShared(c *C) {
+	h := History{}
+	h.Add("production", "production-css", "app.1.css")
+	h.Add("production", "production-css", "app.2.css")
+	h.Add("mobile", "mobile-css", "app.1.css")
+
+	// The stylesheet is still the current one of the mobile target
+	c.Check(h.Trim("production", 1), HasLen, 0)
+	c.Check(h["production"]["production-css"], DeepEquals, []string{"app.2.css"})
+
+	// Removed when no target keeps it
+	h.Add("mobile", "mobile-css", "app.2.css")
+	c.Check(h.Trim("mobile", 1), DeepEquals, []string{"app.1.css"})
+}
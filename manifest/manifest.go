@@ -0,0 +1,275 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// Names of the outputs of the build, indexed by target and kind of file
+// (production-js, production-css, ...).
+type Mapping map[string]string
+
+// Output with its Subresource Integrity value.
+type Entry struct {
+	Url       string `json:"url"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+var (
+	// Lines of the Go & YAML formats: "key": "value"
+	quotedLineRe = regexp.MustCompile(`^\s*("(?:[^"\\]|\\.)*")\s*:\s*("(?:[^"\\]|\\.)*"),?\s*$`)
+)
+
+// Writes the mapping to w in one of the supported formats: js, json, go,
+// properties or yaml. The package name is only used by the go format.
+func Write(w io.Writer, m Mapping, format, pkg string) error {
+	var err error
+	switch format {
+	case "", "js", "json":
+		return writeJson(w, m, format)
+
+	case "go":
+		fmt.Fprintf(w, "// Code generated by closurer. DO NOT EDIT.\n\n")
+		fmt.Fprintf(w, "package %s\n\n", pkg)
+		fmt.Fprintf(w, "var Mapping = map[string]string{\n")
+		for _, k := range m.Keys() {
+			fmt.Fprintf(w, "\t%s: %s,\n", strconv.Quote(k), strconv.Quote(m[k]))
+		}
+		_, err = fmt.Fprintf(w, "}\n")
+
+	case "properties":
+		for _, k := range m.Keys() {
+			fmt.Fprintf(w, "%s=%s\n", escapeProperty(k, true), escapeProperty(m[k], false))
+		}
+
+	case "yaml":
+		for _, k := range m.Keys() {
+			fmt.Fprintf(w, "%s: %s\n", quoteJson(k), quoteJson(m[k]))
+		}
+
+	default:
+		return app.Errorf("mapping format not supported: %s", format)
+	}
+
+	if err != nil {
+		return app.Error(err)
+	}
+
+	return nil
+}
+
+// Reads a mapping previously written with the same format.
+func Read(r io.Reader, format string) (Mapping, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, app.Error(err)
+	}
+
+	m := Mapping{}
+	switch format {
+	case "", "js", "json":
+		if err := readJson(content, &m); err != nil {
+			return nil, err
+		}
+
+	case "go", "yaml":
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			matchs := quotedLineRe.FindStringSubmatch(scanner.Text())
+			if matchs == nil {
+				continue
+			}
+
+			k, err := strconv.Unquote(matchs[1])
+			if err != nil {
+				return nil, app.Errorf("cannot read the mapping key %s: %s", matchs[1], err)
+			}
+			v, err := strconv.Unquote(matchs[2])
+			if err != nil {
+				return nil, app.Errorf("cannot read the mapping value %s: %s", matchs[2], err)
+			}
+			m[k] = v
+		}
+
+	case "properties":
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || line[0] == '#' || line[0] == '!' {
+				continue
+			}
+
+			k, v := splitProperty(line)
+			m[k] = v
+		}
+
+	default:
+		return nil, app.Errorf("mapping format not supported: %s", format)
+	}
+
+	return m, nil
+}
+
+// Writes the entries with their integrity values. Only the js and json
+// formats are supported.
+func WriteEntries(w io.Writer, entries map[string]*Entry, format string) error {
+	if format != "" && format != "js" && format != "json" {
+		return app.Errorf("mapping format not supported with integrity values: %s", format)
+	}
+
+	return writeJson(w, entries, format)
+}
+
+// Reads the entries previously written with the same format.
+func ReadEntries(r io.Reader, format string) (map[string]*Entry, error) {
+	if format != "" && format != "js" && format != "json" {
+		return nil, app.Errorf("mapping format not supported with integrity values: %s", format)
+	}
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, app.Error(err)
+	}
+
+	entries := map[string]*Entry{}
+	if err := readJson(content, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Writes the value as a JS variable or as a JSON file.
+func writeJson(w io.Writer, v interface{}, format string) error {
+	if format == "json" {
+		content, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return app.Error(err)
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", content); err != nil {
+			return app.Error(err)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(w, "var mapping = ")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		return app.Error(err)
+	}
+
+	return nil
+}
+
+// Reads a value written as a JS variable or as a JSON file.
+func readJson(content []byte, v interface{}) error {
+	content = bytes.TrimSpace(content)
+	content = bytes.TrimPrefix(content, []byte("var mapping = "))
+	content = bytes.TrimSuffix(content, []byte(";"))
+	if len(content) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(content, v); err != nil {
+		return app.Errorf("cannot read the mapping: %s", err)
+	}
+
+	return nil
+}
+
+// Returns the sorted list of keys.
+func (m Mapping) Keys() []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Copies all the entries of other to the mapping, replacing
+// the repeated ones.
+func (m Mapping) Merge(other Mapping) {
+	for k, v := range other {
+		m[k] = v
+	}
+}
+
+func quoteJson(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func escapeProperty(s string, key bool) string {
+	buf := bytes.NewBuffer(nil)
+	for i, c := range s {
+		switch {
+		case c == '\\' || c == '=' || c == ':' || c == '#' || c == '!':
+			buf.WriteString(`\` + string(c))
+		case c == ' ' && (key || i == 0):
+			buf.WriteString(`\ `)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return buf.String()
+}
+
+// Splits a properties line in its unescaped key and value.
+func splitProperty(line string) (string, string) {
+	key := bytes.NewBuffer(nil)
+	i := 0
+	for ; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' && i+1 < len(line) {
+			i++
+			key.WriteString(unescapeProperty(line[i]))
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' {
+			break
+		}
+		key.WriteByte(c)
+	}
+
+	// Skip the separator and the whitespace around it
+	rest := strings.TrimLeft(line[i:], " \t")
+	if len(rest) > 0 && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t")
+	}
+
+	value := bytes.NewBuffer(nil)
+	for j := 0; j < len(rest); j++ {
+		if rest[j] == '\\' && j+1 < len(rest) {
+			j++
+			value.WriteString(unescapeProperty(rest[j]))
+			continue
+		}
+		value.WriteByte(rest[j])
+	}
+
+	return key.String(), value.String()
+}
+
+func unescapeProperty(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	}
+	return string(c)
+}
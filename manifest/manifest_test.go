@@ -0,0 +1,42 @@
+package manifest
+
+import (
+	"bytes"
+	"testing"
+
+	. "launchpad.net/gocheck"
+)
+
+// Hook up gocheck into the gotest runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type ManifestSuite struct{}
+
+var _ = Suite(&ManifestSuite{})
+
+func (s *ManifestSuite) TestRoundTrip(c *C) {
+	m := Mapping{
+		"production-js":  "static/js/compiled.abc.js",
+		"production-css": "static/css/compiled.abc.css",
+		"weird key=1":    "a \"quoted\": value\\",
+	}
+
+	for _, format := range []string{"js", "json", "go", "properties", "yaml"} {
+		buf := bytes.NewBuffer(nil)
+		c.Assert(Write(buf, m, format, "assets"), IsNil)
+
+		result, err := Read(buf, format)
+		c.Assert(err, IsNil)
+		c.Check(result, DeepEquals, m)
+	}
+}
+
+func (s *ManifestSuite) TestGoFormat(c *C) {
+	buf := bytes.NewBuffer(nil)
+	c.Assert(Write(buf, Mapping{"dev-js": "a.js"}, "go", "assets"), IsNil)
+	c.Check(buf.String(), Equals, "// Code generated by closurer. DO NOT EDIT.\n\n"+
+		"package assets\n\n"+
+		"var Mapping = map[string]string{\n"+
+		"\t\"dev-js\": \"a.js\",\n"+
+		"}\n")
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// Writes the content of r to the file atomically. The data goes to a
+// temporary file in the same folder that replaces the final one only when
+// everything has been written, so a failed build never leaves half-written
+// files behind.
+func Write(name string, r io.Reader) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name)+".tmp")
+	if err != nil {
+		return app.Error(err)
+	}
+
+	if err := write(tmp, name, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// Writes the content to the file atomically.
+func WriteFile(name string, content []byte) error {
+	return Write(name, bytes.NewReader(content))
+}
+
+func write(tmp *os.File, name string, r io.Reader) error {
+	if _, err := io.Copy(tmp, r); err != nil {
+		return app.Error(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return app.Error(err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return app.Error(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return app.Error(err)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return app.Error(err)
+	}
+
+	return nil
+}
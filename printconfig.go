@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+)
+
+// Dumps the JS and GSS settings of the current target once
+// its inheritance chain has been resolved.
+func printConfig() error {
+	conf := config.Current()
+
+	fmt.Printf("<!-- Target %s -->\n", config.SelectedTarget)
+
+	if t := conf.Js.CurTarget(); t != nil {
+		if err := printNode("js", t); err != nil {
+			return err
+		}
+	}
+	if t := conf.Gss.CurTarget(); t != nil {
+		if err := printNode("gss", t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printNode(parent string, target interface{}) error {
+	fmt.Printf("<%s>\n", parent)
+
+	e := xml.NewEncoder(os.Stdout)
+	e.Indent("  ", "  ")
+	if err := e.EncodeElement(target, xml.StartElement{Name: xml.Name{Local: "target"}}); err != nil {
+		return app.Error(err)
+	}
+
+	fmt.Printf("\n</%s>\n", parent)
+	return nil
+}
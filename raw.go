@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"html/template"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"path"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/ernestokarim/closurer/app"
 	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/css"
 	"github.com/ernestokarim/closurer/gss"
 	"github.com/ernestokarim/closurer/hooks"
 	"github.com/ernestokarim/closurer/js"
@@ -59,11 +59,10 @@ func RawOutput(r *app.Request) error {
 		return err
 	}
 
-	css := make([]byte, 0)
-	if conf.Gss != nil {
-		css, err = ioutil.ReadFile(filepath.Join(conf.Build, config.CSS_NAME))
-		if err != nil {
-			return app.Error(err)
+	styles := bytes.NewBuffer(nil)
+	if conf.Gss.CurTarget() != nil {
+		if err := writeCss(styles, r); err != nil {
+			return err
 		}
 	}
 
@@ -72,12 +71,88 @@ func RawOutput(r *app.Request) error {
 		"Port":       config.Port,
 		"LT":         template.HTML("<"),
 		"Namespaces": template.HTML("'" + strings.Join(namespaces, "', '") + "'"),
-		"Css":        template.HTML(template.JSEscapeString(string(css))),
+		"Css":        template.HTML(template.JSEscapeString(styles.String())),
 	}
 	r.W.Header().Set("Content-Type", "text/javascript")
 	return r.ExecuteTemplate([]string{"raw"}, data)
 }
 
+// Serves the compiled stylesheet (only needed if the target is not RAW,
+// otherwise it's injected by the JS code).
+func CompiledCss(r *app.Request) error {
+	if err := hooks.PreCompile(); err != nil {
+		return err
+	}
+
+	if err := gss.Compile(); err != nil {
+		return err
+	}
+
+	if err := hooks.PostCompile(); err != nil {
+		return err
+	}
+
+	conf := config.Current()
+	if conf.Gss.CurTarget() == nil {
+		return app.NotFound()
+	}
+
+	r.W.Header().Set("Content-Type", "text/css")
+	return writeCss(r.W, r)
+}
+
+// Writes the stylesheet asked in the request followed by its plain CSS
+// files, as the build does.
+func writeCss(w io.Writer, r *app.Request) error {
+	name, plain, err := requestedCss(r)
+	if err != nil {
+		return err
+	}
+
+	if err := addFile(w, name); err != nil {
+		return err
+	}
+
+	files := []string{}
+	for _, n := range plain {
+		files = append(files, n.File)
+	}
+	return css.Bundle(w, files, filepath.Dir(name))
+}
+
+// Returns the compiled stylesheet asked in the request and its plain CSS
+// files: the flipped one with ?rtl=true, and a bundle instead of the main
+// one with ?bundle=name.
+func requestedCss(r *app.Request) (string, []*config.InputNode, error) {
+	conf := config.Current()
+
+	target := conf.Gss.CurTarget()
+	if target == nil {
+		return "", nil, app.Errorf("the target doesn't compile GSS code: %s", config.SelectedTarget)
+	}
+
+	rtl := r.Req.FormValue("rtl") == "true"
+	if rtl && target.Rtl != "true" {
+		return "", nil, app.Errorf("the target doesn't compile a RTL stylesheet: %s",
+			config.SelectedTarget)
+	}
+
+	name, plain := config.CSS_NAME, conf.Gss.Css
+	if rtl {
+		name = config.CSS_RTL_NAME
+	}
+
+	if bundle := r.Req.FormValue("bundle"); bundle != "" {
+		b := conf.Gss.Bundle(bundle)
+		if b == nil {
+			return "", nil, app.Errorf("GSS bundle not found: %s", bundle)
+		}
+		name, plain = config.BundleCssName(bundle, rtl), b.Css
+	}
+
+	return filepath.Join(conf.Build, name), plain, nil
+}
+
 func addFile(w io.Writer, name string) error {
 	f, err := os.Open(name)
 	if err != nil {
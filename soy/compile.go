@@ -3,7 +3,6 @@ package soy
 import (
 	"log"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 
@@ -76,9 +75,7 @@ func Compile() error {
 		log.Println("Compiling template", t, "...")
 
 		// Run the compiler command
-		cmd := exec.Command(
-			"java",
-			"-jar", path.Join(conf.Soy.Compiler, "build", "SoyToJsSrcCompiler.jar"),
+		cmd := conf.Soy.Command(conf.Soy.Jar(),
 			"--outputPathFormat", out,
 			"--shouldGenerateJsdoc",
 			"--shouldProvideRequireSoyNamespaces",
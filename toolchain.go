@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/toolchain"
+)
+
+// Runs the toolchain subcommands, i.e. "toolchain install".
+func toolchainCmd(args []string) error {
+	if len(args) != 1 {
+		return app.Errorf("Usage: closurer -conf <file> [-mirror <folder>] toolchain install")
+	}
+
+	switch args[0] {
+	case "install":
+		// The rest of the config may need the tools
+		tc, err := config.ReadToolchain()
+		if err != nil {
+			return err
+		}
+		return toolchain.Install(tc)
+	}
+
+	return app.Errorf("toolchain command not supported: %s", args[0])
+}
@@ -0,0 +1,168 @@
+package toolchain
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+)
+
+// Extracts the archive in the folder, removing the top level folder
+// if all the files are inside it. Jars and compilers released with
+// the jar in the root are moved to the build folder, where the
+// rest of the app expects them.
+func extract(archive, name, dir, jar string) error {
+	raw := dir + ".raw"
+	defer os.RemoveAll(raw)
+
+	var err error
+	switch {
+	case strings.HasSuffix(name, ".jar"):
+		if jar == "" {
+			return app.Errorf("the tool can't be installed from a jar: %s", name)
+		}
+		err = copyEntry(raw, jar, 0644, archive)
+
+	case strings.HasSuffix(name, ".zip"):
+		err = extractZip(archive, raw)
+
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		err = extractTar(archive, raw)
+
+	default:
+		return app.Errorf("archive format not supported: %s", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	root := raw
+	entries, err := ioutil.ReadDir(raw)
+	if err != nil {
+		return app.Error(err)
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(raw, entries[0].Name())
+	}
+	if err := os.Rename(root, dir); err != nil {
+		return app.Error(err)
+	}
+
+	if jar != "" {
+		build := filepath.Join(dir, "build")
+		if _, err := os.Stat(filepath.Join(build, jar)); os.IsNotExist(err) {
+			if _, err := os.Stat(filepath.Join(dir, jar)); err != nil {
+				return app.Errorf("jar not found in the archive: %s", jar)
+			}
+			if err := os.MkdirAll(build, 0755); err != nil {
+				return app.Error(err)
+			}
+			if err := os.Rename(filepath.Join(dir, jar), filepath.Join(build, jar)); err != nil {
+				return app.Error(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func extractZip(archive, dir string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return app.Error(err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return app.Error(err)
+		}
+		err = writeEntry(dir, f.Name, f.Mode(), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractTar(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return app.Error(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return app.Error(err)
+	}
+	defer gz.Close()
+
+	r := tar.NewReader(gz)
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return app.Error(err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeEntry(dir, hdr.Name, os.FileMode(hdr.Mode), r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyEntry(dir, name string, mode os.FileMode, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return app.Error(err)
+	}
+	defer f.Close()
+
+	return writeEntry(dir, name, mode, f)
+}
+
+// Writes a file of the archive, refusing the ones that
+// would end outside the folder.
+func writeEntry(dir, name string, mode os.FileMode, r io.Reader) error {
+	name = filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return app.Errorf("illegal path in the archive: %s", name)
+	}
+
+	filename := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return app.Error(err)
+	}
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return app.Error(err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return app.Error(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package toolchain
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+	"github.com/ernestokarim/closurer/digest"
+)
+
+// Checksum of the archive a tool was installed from.
+const STAMP_NAME = ".sha256"
+
+// Name of the jar each compiler needs inside its build folder.
+var jars = map[string]string{
+	"compiler":    "compiler.jar",
+	"stylesheets": "closure-stylesheets.jar",
+	"templates":   "SoyToJsSrcCompiler.jar",
+}
+
+// Installs the pinned tools in the shared cache, skipping the
+// ones that are already there.
+func Install(tc *config.ToolchainNode) error {
+	if tc == nil || len(tc.Tools) == 0 {
+		return app.Errorf("No tool pinned in the config")
+	}
+
+	for _, tool := range tc.Tools {
+		if err := InstallTool(tc, tool); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns true if the pinned version of the tool is
+// already installed in the cache.
+func Installed(tc *config.ToolchainNode, tool *config.ToolNode) bool {
+	stamp, err := ioutil.ReadFile(filepath.Join(tc.Dir(tool), STAMP_NAME))
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(stamp)) == strings.ToLower(tool.Sha256)
+}
+
+// Fetches the archive of the tool, verifies its checksum and
+// extracts it in the cache.
+func InstallTool(tc *config.ToolchainNode, tool *config.ToolNode) error {
+	if Installed(tc, tool) {
+		log.Printf("%s %s already installed\n", tool.Name, tool.Version)
+		return nil
+	}
+
+	f, err := ioutil.TempFile("", "closurer-toolchain")
+	if err != nil {
+		return app.Error(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := fetch(tc, tool, f); err != nil {
+		return err
+	}
+
+	sum, err := digest.File(f.Name(), "sha256", 0)
+	if err != nil {
+		return err
+	}
+	if sum != strings.ToLower(tool.Sha256) {
+		return app.Errorf("checksum mismatch of %s %s: %s", tool.Name, tool.Version, sum)
+	}
+
+	dir := tc.Dir(tool)
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return app.Error(err)
+	}
+	if err := extract(f.Name(), path.Base(tool.Url), tmp, jars[tool.Name]); err != nil {
+		return err
+	}
+
+	stamp := filepath.Join(tmp, STAMP_NAME)
+	if err := ioutil.WriteFile(stamp, []byte(sum+"\n"), 0644); err != nil {
+		return app.Error(err)
+	}
+
+	// Replace the old installation, if any
+	if err := os.RemoveAll(dir); err != nil {
+		return app.Error(err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return app.Error(err)
+	}
+
+	log.Printf("%s %s installed in %s\n", tool.Name, tool.Version, dir)
+
+	return nil
+}
+
+// Copies the archive of the tool from the mirror folder if there's
+// one, or downloads it from its url otherwise.
+func fetch(tc *config.ToolchainNode, tool *config.ToolNode, w io.Writer) error {
+	if tc.Mirror != "" {
+		f, err := os.Open(filepath.Join(tc.Mirror, path.Base(tool.Url)))
+		if err != nil {
+			return app.Error(err)
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(w, f); err != nil {
+			return app.Error(err)
+		}
+		return nil
+	}
+
+	log.Printf("Downloading %s\n", tool.Url)
+
+	resp, err := http.Get(tool.Url)
+	if err != nil {
+		return app.Error(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return app.Errorf("cannot download %s: %s", tool.Url, resp.Status)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return app.Error(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package toolchain
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ernestokarim/closurer/app"
+	"github.com/ernestokarim/closurer/config"
+
+	. "launchpad.net/gocheck"
+)
+
+// Hook up gocheck into the gotest runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type InstallSuite struct{}
+
+var _ = Suite(&InstallSuite{})
+
+func (s *InstallSuite) TestMirror(c *C) {
+	mirror := c.MkDir()
+	archive := filepath.Join(mirror, "compiler-20130227.zip")
+	writeZip(c, archive, map[string]string{
+		"compiler-20130227/compiler.jar": "jar",
+		"compiler-20130227/README":       "readme",
+	})
+
+	tc := &config.ToolchainNode{Cache: c.MkDir(), Mirror: mirror}
+	tool := &config.ToolNode{
+		Name:    "compiler",
+		Version: "20130227",
+		Url:     "http://example.com/compiler-20130227.zip",
+		Sha256:  sha256File(c, archive),
+	}
+	c.Assert(Installed(tc, tool), Equals, false)
+	c.Assert(InstallTool(tc, tool), IsNil)
+	c.Assert(Installed(tc, tool), Equals, true)
+
+	content, err := ioutil.ReadFile(filepath.Join(tc.Dir(tool), "build", "compiler.jar"))
+	c.Assert(err, IsNil)
+	c.Check(string(content), Equals, "jar")
+
+	_, err = os.Stat(filepath.Join(tc.Dir(tool), "README"))
+	c.Check(err, IsNil)
+}
+
+func (s *InstallSuite) TestChecksumMismatch(c *C) {
+	mirror := c.MkDir()
+	writeZip(c, filepath.Join(mirror, "library.zip"), map[string]string{
+		"closure/goog/base.js": "",
+	})
+
+	tc := &config.ToolchainNode{Cache: c.MkDir(), Mirror: mirror}
+	tool := &config.ToolNode{
+		Name:    "library",
+		Version: "r2388",
+		Url:     "http://example.com/library.zip",
+		Sha256:  "0000000000000000000000000000000000000000000000000000000000000000",
+	}
+	err := InstallTool(tc, tool)
+	c.Assert(err, NotNil)
+	c.Check(err.(*app.AppError).OriginalErr, ErrorMatches, "checksum mismatch of library r2388: .*")
+	c.Check(Installed(tc, tool), Equals, false)
+}
+
+func writeZip(c *C, filename string, files map[string]string) {
+	f, err := os.Create(filename)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		c.Assert(err, IsNil)
+		_, err = fw.Write([]byte(content))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(w.Close(), IsNil)
+}
+
+func sha256File(c *C, filename string) string {
+	content, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}